@@ -3,11 +3,14 @@ package main
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 
 	redmine "github.com/uphy/go-redmine"
 
-	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/uphy/redmine-sync/sync"
 	"github.com/urfave/cli"
@@ -22,26 +25,140 @@ func main() {
 
 	var endpoint string
 	var apikey string
+	var closedStatus string
+	var openStatus string
+	var csvEncoding string
+	var delimiter string
+	var holidays string
+	var profileName string
+	profile := &sync.Profile{}
+	transforms := &cli.StringSlice{}
 
 	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name:        "profile",
+			Usage:       "profile of the config files (~/.config/redmine-sync/config.yml, .redmine-sync.yml)",
+			EnvVar:      "REDMINE_SYNC_PROFILE",
+			Destination: &profileName,
+		},
+		// the environment variables are read in Before, not to mix them with the options and the profile
 		cli.StringFlag{
 			Name:        "apikey",
-			EnvVar:      "REDMINE_APIKEY",
+			Usage:       "API key, or $REDMINE_APIKEY",
 			Destination: &apikey,
 		},
 		cli.StringFlag{
 			Name:        "endpoint",
-			EnvVar:      "REDMINE_ENDPOINT",
+			Usage:       "URL of the Redmine server, or $REDMINE_ENDPOINT",
 			Destination: &endpoint,
 		},
+		cli.StringFlag{
+			Name:        "closed-status",
+			Usage:       "status of the checked tickets in markdown files",
+			Value:       "Closed",
+			Destination: &closedStatus,
+		},
+		cli.StringFlag{
+			Name:        "open-status",
+			Usage:       "status of the unchecked closed tickets in markdown files (default: the first open status)",
+			Destination: &openStatus,
+		},
+		cli.StringFlag{
+			Name:        "csv-encoding",
+			Usage:       "encoding of the written csv files (utf-8, utf-8-bom, shift_jis)",
+			Value:       sync.EncodingUTF8,
+			Destination: &csvEncoding,
+		},
+		cli.StringFlag{
+			Name:        "delimiter",
+			Usage:       "field delimiter of the csv files",
+			Value:       ",",
+			Destination: &delimiter,
+		},
+		cli.StringFlag{
+			Name:        "holidays",
+			Usage:       "file of the holidays of YYYY-MM-DD per line, which are not working days",
+			Destination: &holidays,
+		},
+		cli.StringSliceFlag{
+			Name:  "transform",
+			Usage: "transform plugin (redmine-sync-transform-<name> on PATH) applied before importing",
+			Value: transforms,
+		},
+	}
+
+	// keyOfProfile is true if the API key is taken from the profile, which may run the command of the key
+	keyOfProfile := false
+	// newOfflineSync doesn't require the endpoint and the API key for the commands which don't access the server
+	newOfflineSync := func() (*sync.Sync, error) {
+		s, err := sync.New(endpoint, apikey)
+		if err != nil {
+			return nil, err
+		}
+		s.Converter.ClosedStatus = closedStatus
+		s.Converter.OpenStatus = openStatus
+		s.Converter.CSVEncoding = csvEncoding
+		if delimiter == "\\t" {
+			delimiter = "\t"
+		}
+		if utf8.RuneCountInString(delimiter) != 1 {
+			return nil, errors.New("delimiter must be a character: " + delimiter)
+		}
+		s.Converter.CSVDelimiter, _ = utf8.DecodeRuneInString(delimiter)
+		s.Converter.Transforms = *transforms
+		if holidays != "" {
+			h, err := sync.LoadHolidays(holidays)
+			if err != nil {
+				return nil, err
+			}
+			s.Converter.Holidays = h
+		}
+		return s, nil
+	}
+	newSync := func() (*sync.Sync, error) {
+		if keyOfProfile {
+			key, err := profile.Key()
+			if err != nil {
+				return nil, err
+			}
+			apikey = key
+			keyOfProfile = false
+		}
+		if apikey == "" {
+			return nil, errors.New("apikey is required")
+		}
+		if endpoint == "" {
+			return nil, errors.New("endpoint is required")
+		}
+		return newOfflineSync()
 	}
 
 	app.Before = func(ctx *cli.Context) error {
-		if !ctx.IsSet("apikey") {
-			return errors.New("apikey is required")
+		profiles, err := sync.LoadProfiles(sync.ProfileFiles())
+		if err != nil {
+			return err
+		}
+		p, err := profiles.Profile(profileName)
+		if err != nil {
+			return err
 		}
-		if !ctx.IsSet("endpoint") {
-			return errors.New("endpoint is required")
+		*profile = *p
+		// the endpoint and the key are taken from the same source not to send the key to another server,
+		// the options first, then the selected profile, the environment variables and the default profile
+		switch {
+		case ctx.IsSet("endpoint") || ctx.IsSet("apikey"):
+			if !ctx.IsSet("endpoint") || !ctx.IsSet("apikey") {
+				return errors.New("endpoint and apikey options must be set together")
+			}
+		case profileName == "" && (os.Getenv("REDMINE_ENDPOINT") != "" || os.Getenv("REDMINE_APIKEY") != ""):
+			endpoint = os.Getenv("REDMINE_ENDPOINT")
+			apikey = os.Getenv("REDMINE_APIKEY")
+			if endpoint == "" || apikey == "" {
+				return errors.New("REDMINE_ENDPOINT and REDMINE_APIKEY must be set together")
+			}
+		default:
+			endpoint = profile.Endpoint
+			keyOfProfile = true
 		}
 		return nil
 	}
@@ -59,7 +176,7 @@ func main() {
 				if ctx.NArg() != 1 {
 					return errors.New("specify a file to watch")
 				}
-				s, err := sync.New(endpoint, apikey)
+				s, err := newSync()
 				if err != nil {
 					return err
 				}
@@ -70,25 +187,48 @@ func main() {
 			Name: "import",
 			Flags: []cli.Flag{
 				cli.StringFlag{
-					Name: "base,b",
+					Name:  "base,b",
+					Usage: "config of the issues before the changes, only whose differences are imported (default: the base snapshot of the file)",
+				},
+				cli.StringFlag{
+					Name:  "format",
+					Usage: "format of the stdin; the format of the files is detected from the extensions",
+				},
+				cli.StringFlag{
+					Name:  "output,o",
+					Usage: "file to write the imported config with the created IDs, - for stdout",
 				},
 			},
-			ArgsUsage: "[file]",
+			ArgsUsage: "[file|-]",
 			Action: func(ctx *cli.Context) error {
 				var file string
 				if ctx.NArg() != 1 {
 					return errors.New("specify a file to import")
 				}
 				file = ctx.Args().First()
-				f, err := os.Open(file)
-				if err != nil {
-					return err
+				if ctx.IsSet("format") && file != "-" {
+					return errors.New("format is only for the stdin; the format of the file is detected from the extension")
+				}
+				var in io.Reader = os.Stdin
+				if file != "-" {
+					f, err := os.Open(file)
+					if err != nil {
+						return err
+					}
+					defer f.Close()
+					in = f
 				}
-				defer f.Close()
 
-				var base *os.File
-				if ctx.IsSet("base") {
-					f, err := os.Open(ctx.String("base"))
+				// the base snapshot of the last sync is the default base
+				var base io.Reader
+				baseFile := ctx.String("base")
+				if !ctx.IsSet("base") && file != "-" {
+					if _, err := os.Stat(sync.BaseFile(file)); err == nil {
+						baseFile = sync.BaseFile(file)
+					}
+				}
+				if baseFile != "" {
+					f, err := os.Open(baseFile)
 					if err != nil {
 						return err
 					}
@@ -96,77 +236,507 @@ func main() {
 					base = f
 				}
 
-				s, err := sync.New(endpoint, apikey)
+				s, err := newSync()
 				if err != nil {
 					return err
 				}
+				// the stdout is written in the format of the stdin, or of the file
+				s.Converter.StreamFormat = ctx.String("format")
+				if info, err := os.Stat(file); err == nil && !info.IsDir() {
+					format, err := sync.FormatOfFile(file)
+					if err != nil {
+						return err
+					}
+					s.Converter.StreamFormat = format.Name()
+				}
 
-				config, changed, err := s.ImportFile(f, base)
+				config, changed, err := s.ImportFile(in, base)
 				if err != nil {
 					return err
 				}
-				if changed && file != "" {
-					f, err := os.Create(file)
-					if err != nil {
+				output := file
+				if ctx.IsSet("output") {
+					output = ctx.String("output")
+				}
+				if output == "-" {
+					// the stdin is written back to the stdout whether changed or not, to be used in pipes
+					return s.Converter.SaveConfig(os.Stdout, config)
+				}
+				if changed || output != file {
+					if err := s.Converter.SaveConfigFile(output, config); err != nil {
 						return err
 					}
-					defer f.Close()
-					return s.Converter.SaveConfig(f, config)
 				}
-				return nil
+				return s.Converter.SaveBase(output, config)
 			},
 		},
 		cli.Command{
-			Name: "export",
+			Name:  "new",
+			Usage: "create the tickets of a ticket tree template",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "template",
+					Usage: "ticket tree template file with the placeholders such as {{.version}}",
+				},
+				cli.StringSliceFlag{
+					Name:  "var",
+					Usage: "variable of the template as key=value",
+				},
+				cli.StringFlag{
+					Name:  "output,o",
+					Usage: "file to write the created tickets (default: the stdout)",
+				},
+			},
+			Action: func(ctx *cli.Context) error {
+				if !ctx.IsSet("template") {
+					return errors.New("specify a template file")
+				}
+				vars := map[string]string{}
+				for _, v := range ctx.StringSlice("var") {
+					kv := strings.SplitN(v, "=", 2)
+					if len(kv) != 2 {
+						return errors.New("variable must be key=value: " + v)
+					}
+					vars[kv[0]] = kv[1]
+				}
+				s, err := newSync()
+				if err != nil {
+					return err
+				}
+				config, err := s.ImportTemplate(ctx.String("template"), vars)
+				if err != nil {
+					return err
+				}
+				if ctx.IsSet("output") {
+					return s.Converter.SaveConfigFile(ctx.String("output"), config)
+				}
+				// the created tickets are written to the stdout in the format of the template not to lose the IDs
+				format, err := sync.FormatOfFile(ctx.String("template"))
+				if err != nil {
+					return err
+				}
+				s.Converter.StreamFormat = format.Name()
+				return s.Converter.SaveConfig(os.Stdout, config)
+			},
+		},
+		cli.Command{
+			Name:      "schedule",
+			Usage:     "compute the dates of the tickets from the durations and the dependencies",
+			ArgsUsage: "[file]",
 			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "push",
+					Usage: "update the dates of the rescheduled issues",
+				},
+			},
+			Action: func(ctx *cli.Context) error {
+				if ctx.NArg() != 1 {
+					return errors.New("specify a file to schedule")
+				}
+				s, err := newSync()
+				if err != nil {
+					return err
+				}
+				changes, err := s.ScheduleFile(ctx.Args().First(), ctx.Bool("push"))
+				if err != nil {
+					return err
+				}
+				for _, change := range changes {
+					t := change.Ticket
+					subject := ""
+					if t.Subject != nil {
+						subject = *t.Subject
+					}
+					fmt.Printf("#%d %s: %s..%s -> %s..%s\n", t.ID, subject, change.StartDate, change.DueDate, *t.StartDate, *t.DueDate)
+				}
+				return nil
+			},
+		},
+		cli.Command{
+			Name:      "diff",
+			Usage:     "show the differences between the file and the issues on the server",
+			ArgsUsage: "[file]",
+			Flags: append([]cli.Flag{
+				cli.StringFlag{
+					Name:  "color",
+					Value: "auto",
+					Usage: "auto, always or never",
+				},
+				// the projects are given by the file
+			}, issueFilterFlags...),
+			Action: func(ctx *cli.Context) error {
+				if ctx.NArg() != 1 {
+					return errors.New("specify a file to diff")
+				}
+				color, err := colorEnabled(ctx.String("color"))
+				if err != nil {
+					return err
+				}
+				s, err := newSync()
+				if err != nil {
+					return err
+				}
+				file := ctx.Args().First()
+				f, err := os.Open(file)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				config, err := s.Converter.ReadConfig(f)
+				if err != nil {
+					return err
+				}
+				// the projects of the file are compared, and the other filters narrow the issues of the projects
+				changes, err := s.DiffServer(config, issueFilterOptions(ctx, profile))
+				if err != nil {
+					return err
+				}
+				sync.WriteDiff(os.Stdout, changes, "server", file, color)
+				if len(changes) > 0 {
+					return cli.NewExitError("", 1)
+				}
+				return nil
+			},
+		},
+		cli.Command{
+			Name:      "status",
+			Usage:     "show the changes of the file and the server since the last sync",
+			ArgsUsage: "[file]",
+			Flags: append([]cli.Flag{
+				cli.BoolFlag{
+					Name:  "offline",
+					Usage: "don't check the changes on the server",
+				},
 				cli.StringFlag{
-					Name: "project",
+					Name:  "color",
+					Value: "auto",
+					Usage: "auto, always or never",
+				},
+				// the projects are given by the file
+			}, issueFilterFlags...),
+			Action: func(ctx *cli.Context) error {
+				if ctx.NArg() != 1 {
+					return errors.New("specify a file")
+				}
+				color, err := colorEnabled(ctx.String("color"))
+				if err != nil {
+					return err
+				}
+				s, err := newSync()
+				if err != nil {
+					return err
+				}
+				file := ctx.Args().First()
+				status, err := s.Status(file, !ctx.Bool("offline"), issueFilterOptions(ctx, profile))
+				if err != nil {
+					return err
+				}
+				status.WriteText(os.Stdout, file, color)
+				return nil
+			},
+		},
+		cli.Command{
+			Name:      "validate",
+			Usage:     "report the problems of the file",
+			ArgsUsage: "[file]",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "offline",
+					Usage: "skip the checks of the names on the server",
 				},
+			},
+			Action: func(ctx *cli.Context) error {
+				if ctx.NArg() != 1 {
+					return errors.New("specify a file to validate")
+				}
+				newValidateSync := newSync
+				if ctx.Bool("offline") {
+					newValidateSync = newOfflineSync
+				}
+				s, err := newValidateSync()
+				if err != nil {
+					return err
+				}
+				problems, err := s.Validate(ctx.Args().First(), !ctx.Bool("offline"))
+				if err != nil {
+					return err
+				}
+				for _, p := range problems {
+					fmt.Println(p)
+				}
+				if len(problems) > 0 {
+					return cli.NewExitError(fmt.Sprintf("%d problem(s) found", len(problems)), 1)
+				}
+				return nil
+			},
+		},
+		cli.Command{
+			Name: "analyze",
+			Subcommands: []cli.Command{
+				cli.Command{
+					Name:      "critical-path",
+					Usage:     "report the critical path, the slack of the tickets and the tickets due too late",
+					ArgsUsage: "[file]",
+					Flags: append([]cli.Flag{
+						cli.StringFlag{
+							Name:  "format",
+							Value: "text",
+							Usage: "text or json",
+						},
+						projectFlag,
+					}, issueFilterFlags...),
+					Action: func(ctx *cli.Context) error {
+						s, err := newSync()
+						if err != nil {
+							return err
+						}
+						var config *sync.Config
+						if ctx.NArg() > 0 {
+							f, err := os.Open(ctx.Args().First())
+							if err != nil {
+								return err
+							}
+							defer f.Close()
+							config, err = s.Converter.ReadConfig(f)
+							if err != nil {
+								return err
+							}
+						} else {
+							filter, err := issueFilter(ctx, s, profile)
+							if err != nil {
+								return err
+							}
+							config, err = s.Export(filter, os.Stdout)
+							if err != nil {
+								return err
+							}
+						}
+						report, err := s.AnalyzeCriticalPath(config)
+						if err != nil {
+							return err
+						}
+						switch ctx.String("format") {
+						case "text":
+							return report.WriteText(os.Stdout)
+						case "json":
+							return report.WriteJSON(os.Stdout)
+						default:
+							return errors.New("unsupported format: " + ctx.String("format"))
+						}
+					},
+				},
+			},
+		},
+		cli.Command{
+			Name: "export",
+			Flags: append([]cli.Flag{
 				cli.StringFlag{
-					Name: "status",
+					Name:  "query",
+					Usage: "name or ID of the saved query to export",
 				},
 				cli.StringFlag{
 					Name:  "format",
 					Value: "yaml",
+					Usage: strings.Join(sync.FormatNames(), ", ") + ", or <name> of the plugin " + sync.FormatPluginPrefix + "<name> on PATH",
 				},
-			},
+				cli.StringFlag{
+					Name:  "ics-assignee",
+					Usage: "restrict the ics feed to the tickets of the assignee",
+				},
+				cli.BoolFlag{
+					Name:  "ics-todo",
+					Usage: "write the tickets of the ics feed as VTODO",
+				},
+				cli.BoolFlag{
+					Name:  "relations",
+					Usage: "draw the issue relations in the dot graph",
+				},
+				cli.StringFlag{
+					Name:  "output,o",
+					Usage: "output file, or directory of the dir format",
+				},
+				cli.StringFlag{
+					Name:  "template",
+					Usage: "text/template file executed with the exported tickets instead of the format",
+				},
+				projectFlag,
+			}, issueFilterFlags...),
 			Action: func(ctx *cli.Context) error {
-				s, err := sync.New(endpoint, apikey)
+				s, err := newSync()
 				if err != nil {
 					return err
 				}
-				filter := &redmine.IssueFilter{}
-				if ctx.IsSet("project") {
-					id, err := s.Converter.Projects.FindIDByName(ctx.String("project"))
-					if err != nil {
-						return err
+				var config *sync.Config
+				if ctx.IsSet("query") {
+					for _, name := range []string{"status", "tracker", "assignee", "updated-since", "include-subprojects"} {
+						if ctx.IsSet(name) {
+							return errors.New(name + " can't be used with query")
+						}
 					}
-					filter.ProjectId = strconv.Itoa(id)
-				}
-				if ctx.IsSet("status") {
-					id, err := s.Converter.Statuses.FindIDByName(ctx.String("status"))
+					// the query is scoped only by the project option, not by the profile
+					projectID := 0
+					if ctx.IsSet("project") {
+						projectID, err = s.Converter.Projects.FindIDByName(ctx.String("project"))
+						if err != nil {
+							return err
+						}
+					}
+					config, err = s.ExportQuery(ctx.String("query"), projectID)
+				} else {
+					var filter *redmine.IssueFilter
+					filter, err = issueFilter(ctx, s, profile)
 					if err != nil {
 						return err
 					}
-					filter.StatusId = strconv.Itoa(id)
+					config, err = s.Export(filter, os.Stdout)
 				}
-				config, err := s.Export(filter, os.Stdout)
 				if err != nil {
 					return err
 				}
+				if ctx.IsSet("template") {
+					return s.Converter.SaveConfigTemplate(os.Stdout, config, ctx.String("template"))
+				}
+				name := "csv"
+				if profile.Format != "" {
+					name = profile.Format
+				}
 				if ctx.IsSet("format") {
-					format := ctx.String("format")
-					switch format {
-					case "yaml":
-						return s.Converter.SaveConfigYAML(os.Stdout, config)
-					case "csv":
-						return s.Converter.SaveConfigCSV(os.Stdout, config)
-					default:
-						return errors.New("unsupported format: " + format)
+					name = ctx.String("format")
+				}
+				format, err := sync.FindFormat(name)
+				if err != nil {
+					return err
+				}
+				// the output file is written in the format of its extension unless the format is given
+				if output := ctx.String("output"); output != "" && !ctx.IsSet("format") && filepath.Ext(output) != "" {
+					format, err = sync.FormatOfFile(output)
+					if err != nil {
+						return err
 					}
-				} else {
-					return s.Converter.SaveConfigCSV(os.Stdout, config)
 				}
+				s.Converter.GraphRelations = ctx.Bool("relations")
+				s.Converter.CalendarAssignee = ctx.String("ics-assignee")
+				s.Converter.CalendarTodo = ctx.Bool("ics-todo")
+				if !ctx.IsSet("output") {
+					return format.Write(s.Converter, os.Stdout, config)
+				}
+				if err := s.Converter.SaveConfigFileAs(ctx.String("output"), format, config); err != nil {
+					return err
+				}
+				// the base snapshot is only for the files which can be imported
+				if !sync.CanRead(format) {
+					return nil
+				}
+				return s.Converter.SaveBase(ctx.String("output"), config)
+			},
+		},
+		cli.Command{
+			Name: "report",
+			Subcommands: []cli.Command{
+				cli.Command{
+					Name: "html",
+					Flags: append([]cli.Flag{
+						cli.StringFlag{
+							Name: "output,o",
+						},
+						projectFlag,
+					}, issueFilterFlags...),
+					Action: func(ctx *cli.Context) error {
+						if !ctx.IsSet("output") {
+							return errors.New("specify an output directory")
+						}
+						s, err := newSync()
+						if err != nil {
+							return err
+						}
+						filter, err := issueFilter(ctx, s, profile)
+						if err != nil {
+							return err
+						}
+						config, err := s.Export(filter, os.Stdout)
+						if err != nil {
+							return err
+						}
+						return s.Converter.SaveReportHTML(ctx.String("output"), config)
+					},
+				},
+			},
+		},
+		cli.Command{
+			Name: "wiki",
+			Subcommands: []cli.Command{
+				cli.Command{
+					Name: "export",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name: "project",
+						},
+						cli.StringFlag{
+							Name:  "format",
+							Value: "textile",
+						},
+					},
+					ArgsUsage: "[dir]",
+					Action: func(ctx *cli.Context) error {
+						if ctx.NArg() != 1 {
+							return errors.New("specify a directory to export")
+						}
+						project := profile.Project
+						if ctx.IsSet("project") {
+							project = ctx.String("project")
+						}
+						if project == "" {
+							return errors.New("project is required")
+						}
+						var extension string
+						format := ctx.String("format")
+						switch format {
+						case "textile":
+							extension = ".textile"
+						case "md", "markdown":
+							extension = ".md"
+						default:
+							return errors.New("unsupported format: " + format)
+						}
+						s, err := newSync()
+						if err != nil {
+							return err
+						}
+						id, err := s.Converter.Projects.FindIDByName(project)
+						if err != nil {
+							return err
+						}
+						return s.ExportWiki(id, ctx.Args().First(), extension)
+					},
+				},
+				cli.Command{
+					Name:      "import",
+					ArgsUsage: "[dir]",
+					Action: func(ctx *cli.Context) error {
+						if ctx.NArg() != 1 {
+							return errors.New("specify a directory to import")
+						}
+						s, err := newSync()
+						if err != nil {
+							return err
+						}
+						return s.ImportWiki(ctx.Args().First())
+					},
+				},
+				cli.Command{
+					Name:      "watch",
+					ArgsUsage: "[dir]",
+					Action: func(ctx *cli.Context) error {
+						if ctx.NArg() != 1 {
+							return errors.New("specify a directory to watch")
+						}
+						s, err := newSync()
+						if err != nil {
+							return err
+						}
+						return s.WatchWiki(ctx.Args().First(), true)
+					},
+				},
 			},
 		},
 	}
@@ -175,3 +745,81 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// projectFlag is the option of the project of the issues read from the server.
+// The commands of the files don't have it because the projects are given by the files.
+var projectFlag = cli.StringFlag{
+	Name: "project",
+}
+
+// issueFilterFlags are the options of the issue filter other than the project, which override the filters of the
+// profile. The values of the status, the tracker and the assignee can be separated by commas.
+var issueFilterFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "status",
+		Usage: "open, closed, all or status names",
+	},
+	cli.StringFlag{
+		Name:  "tracker",
+		Usage: "tracker names",
+	},
+	cli.StringFlag{
+		Name:  "assignee",
+		Usage: "me, none or user names; me can be combined with the names",
+	},
+	cli.StringFlag{
+		Name:  "updated-since",
+		Usage: "date or date expression such as -7d",
+	},
+	cli.BoolFlag{
+		Name:  "include-subprojects",
+		Usage: "include the issues of the subprojects of the project, or exclude them with =false",
+	},
+}
+
+// issueFilter returns the filter of the options, or of the profile for the unset options.
+func issueFilter(ctx *cli.Context, s *sync.Sync, profile *sync.Profile) (*redmine.IssueFilter, error) {
+	options := issueFilterOptions(ctx, profile)
+	return s.Converter.IssueFilter(&options)
+}
+
+func issueFilterOptions(ctx *cli.Context, profile *sync.Profile) sync.IssueFilterOptions {
+	options := profile.Filters
+	options.Project = profile.Project
+	for _, option := range []struct {
+		name  string
+		value *string
+	}{
+		{"project", &options.Project},
+		{"status", &options.Status},
+		{"tracker", &options.Tracker},
+		{"assignee", &options.Assignee},
+		{"updated-since", &options.UpdatedSince},
+	} {
+		if ctx.IsSet(option.name) {
+			*option.value = ctx.String(option.name)
+		}
+	}
+	if ctx.IsSet("include-subprojects") {
+		include := ctx.Bool("include-subprojects")
+		options.IncludeSubprojects = &include
+	}
+	return options
+}
+
+// colorEnabled returns whether to color the output by the color option of auto, always or never.
+func colorEnabled(color string) (bool, error) {
+	switch color {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		if os.Getenv("NO_COLOR") != "" {
+			return false, nil
+		}
+		info, err := os.Stdout.Stat()
+		return err == nil && info.Mode()&os.ModeCharDevice != 0, nil
+	}
+	return false, errors.New("unsupported color: " + color)
+}
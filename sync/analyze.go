@@ -0,0 +1,379 @@
+package sync
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	redmine "github.com/uphy/go-redmine"
+)
+
+type (
+	// CriticalPathReport is the result of the critical path analysis.
+	CriticalPathReport struct {
+		// CriticalPath is the chain of the tickets which determines the end of the project.
+		CriticalPath []*TicketSlack `json:"critical_path"`
+		Tickets      []*TicketSlack `json:"tickets"`
+		Late         []*LateTicket  `json:"late"`
+	}
+	// TicketSlack is the total slack of the ticket in working days.
+	// The slack of the parent is the least slack of the children.
+	TicketSlack struct {
+		ID        int    `json:"id"`
+		Subject   string `json:"subject"`
+		StartDate string `json:"start_date"`
+		DueDate   string `json:"due_date"`
+		Slack     int    `json:"slack"`
+		Critical  bool   `json:"critical"`
+	}
+	// LateTicket is the ticket due after its parent or version.
+	LateTicket struct {
+		ID      int    `json:"id"`
+		Subject string `json:"subject"`
+		DueDate string `json:"due_date"`
+		// Limit is "parent #ID" or "version NAME".
+		Limit        string `json:"limit"`
+		LimitDueDate string `json:"limit_due_date"`
+	}
+
+	cpmNode struct {
+		ticket       *Ticket
+		start        time.Time
+		due          time.Time
+		duration     int
+		predecessors []cpmEdge
+		successors   []cpmEdge
+		lateFinish   time.Time
+		slack        int
+	}
+	cpmEdge struct {
+		node  *cpmNode
+		delay int
+	}
+)
+
+// AnalyzeCriticalPath analyzes the tickets of the config with the relations and the versions fetched from the server.
+func (s *Sync) AnalyzeCriticalPath(config *Config) (*CriticalPathReport, error) {
+	versions := map[int]*redmine.Version{}
+	for _, p := range config.Projects {
+		list, err := s.Converter.versions(p.ID)
+		if err != nil {
+			return nil, err
+		}
+		for i := range list {
+			versions[list[i].Id] = &list[i]
+		}
+	}
+	tickets, err := s.Converter.toFlat(config)
+	if err != nil {
+		return nil, err
+	}
+	ids := []int{}
+	for _, t := range tickets {
+		if t.ID != 0 {
+			ids = append(ids, t.ID)
+		}
+	}
+	// the fixed versions, which are not in the config
+	issues, err := s.Converter.issues(ids)
+	if err != nil {
+		return nil, err
+	}
+	issueVersions := map[int]*redmine.Version{}
+	for _, issue := range issues {
+		if issue.FixedVersion != nil && versions[issue.FixedVersion.Id] != nil {
+			issueVersions[issue.Id] = versions[issue.FixedVersion.Id]
+		}
+	}
+	return s.Converter.AnalyzeCriticalPath(config, issueVersions)
+}
+
+// AnalyzeCriticalPath computes the total slack of the dated tickets and the critical path.
+//
+// The leaf tickets with the due dates are the tasks, and their durations are the working days from the start
+// dates to the due dates.
+// The precedes, follows and blocks relations are the dependencies, and the relations of the parents apply to all
+// of their tasks.
+// The tasks must finish by the end of the project, by the due dates of the successors less their durations, and
+// by the due dates of their ancestors.
+func (c *Converter) AnalyzeCriticalPath(config *Config, issueVersions map[int]*redmine.Version) (*CriticalPathReport, error) {
+	tickets, err := c.toFlat(config)
+	if err != nil {
+		return nil, err
+	}
+	parents := map[*Ticket]*Ticket{}
+	byID := map[int]*Ticket{}
+	for _, t := range tickets {
+		if t.ID != 0 {
+			byID[t.ID] = t
+		}
+		for _, child := range t.Children {
+			parents[child] = t
+		}
+	}
+
+	nodes := map[*Ticket]*cpmNode{}
+	order := []*cpmNode{}
+	for _, t := range tickets {
+		if len(t.Children) > 0 {
+			continue
+		}
+		due, err := parseDate(t.DueDate)
+		if err != nil || due.IsZero() {
+			continue
+		}
+		start, err := parseDate(t.StartDate)
+		if err != nil || start.IsZero() || start.After(due) {
+			start = due
+		}
+		n := &cpmNode{ticket: t, start: start, due: due, duration: c.workdaysBetween(start, due) + 1}
+		if n.duration < 1 {
+			n.duration = 1
+		}
+		nodes[t] = n
+		order = append(order, n)
+	}
+	// tasks returns the tasks of the ticket, which are the dated leaves under the ticket.
+	var tasks func(t *Ticket) []*cpmNode
+	tasks = func(t *Ticket) []*cpmNode {
+		if n, ok := nodes[t]; ok {
+			return []*cpmNode{n}
+		}
+		result := []*cpmNode{}
+		for _, child := range t.Children {
+			result = append(result, tasks(child)...)
+		}
+		return result
+	}
+
+	seen := map[int]bool{}
+	for _, t := range tickets {
+		if t.ID == 0 {
+			continue
+		}
+		relations, err := c.relations(t.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range relations {
+			if seen[r.ID] {
+				continue
+			}
+			seen[r.ID] = true
+			from, to := byID[r.IssueID], byID[r.IssueToID]
+			switch r.RelationType {
+			case RelationPrecedes, RelationBlocks:
+			case RelationFollows:
+				from, to = to, from
+			default:
+				continue
+			}
+			if from == nil || to == nil {
+				continue
+			}
+			delay := 0
+			if r.Delay != nil {
+				delay = *r.Delay
+			}
+			for _, f := range tasks(from) {
+				for _, t := range tasks(to) {
+					if f == t {
+						continue
+					}
+					f.successors = append(f.successors, cpmEdge{t, delay})
+					t.predecessors = append(t.predecessors, cpmEdge{f, delay})
+				}
+			}
+		}
+	}
+
+	// topological order, the predecessors first
+	sorted := []*cpmNode{}
+	indegree := map[*cpmNode]int{}
+	queue := []*cpmNode{}
+	for _, n := range order {
+		indegree[n] = len(n.predecessors)
+		if indegree[n] == 0 {
+			queue = append(queue, n)
+		}
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		sorted = append(sorted, n)
+		for _, e := range n.successors {
+			indegree[e.node]--
+			if indegree[e.node] == 0 {
+				queue = append(queue, e.node)
+			}
+		}
+	}
+	if len(sorted) != len(order) {
+		for _, n := range order {
+			if indegree[n] > 0 {
+				return nil, fmt.Errorf("dependency cycle at #%d", n.ticket.ID)
+			}
+		}
+	}
+
+	var end time.Time
+	for _, n := range order {
+		if n.due.After(end) {
+			end = n.due
+		}
+	}
+	// backward pass
+	for i := len(sorted) - 1; i >= 0; i-- {
+		n := sorted[i]
+		n.lateFinish = end
+		for p := parents[n.ticket]; p != nil; p = parents[p] {
+			if due, err := parseDate(p.DueDate); err == nil && !due.IsZero() && due.Before(n.lateFinish) {
+				n.lateFinish = due
+			}
+		}
+		for _, e := range n.successors {
+			lateStart := c.addWorkdays(e.node.lateFinish, -(e.node.duration - 1))
+			finish := c.addWorkdays(lateStart, -(1 + e.delay))
+			if finish.Before(n.lateFinish) {
+				n.lateFinish = finish
+			}
+		}
+		n.slack = c.workdaysBetween(n.due, n.lateFinish)
+	}
+
+	report := &CriticalPathReport{CriticalPath: []*TicketSlack{}, Tickets: []*TicketSlack{}, Late: []*LateTicket{}}
+	slacks := map[*Ticket]*TicketSlack{}
+	var slackOf func(t *Ticket) *TicketSlack
+	slackOf = func(t *Ticket) *TicketSlack {
+		if s, ok := slacks[t]; ok {
+			return s
+		}
+		var s *TicketSlack
+		if n, ok := nodes[t]; ok {
+			s = newTicketSlack(t, n.slack)
+		} else {
+			for _, child := range t.Children {
+				cs := slackOf(child)
+				if cs != nil && (s == nil || cs.Slack < s.Slack) {
+					s = newTicketSlack(t, cs.Slack)
+				}
+			}
+		}
+		slacks[t] = s
+		return s
+	}
+	for _, t := range tickets {
+		if s := slackOf(t); s != nil {
+			report.Tickets = append(report.Tickets, s)
+		}
+	}
+
+	// the critical path is traced back from the last task through the driving predecessors
+	var last *cpmNode
+	for _, n := range order {
+		if n.due.Equal(end) && (last == nil || n.slack < last.slack) {
+			last = n
+		}
+	}
+	for n := last; n != nil; {
+		report.CriticalPath = append([]*TicketSlack{slacks[n.ticket]}, report.CriticalPath...)
+		var driver *cpmNode
+		for _, e := range n.predecessors {
+			p := e.node
+			if driver == nil || p.slack < driver.slack || (p.slack == driver.slack && p.due.After(driver.due)) {
+				driver = p
+			}
+		}
+		n = driver
+	}
+
+	for _, t := range tickets {
+		due, err := parseDate(t.DueDate)
+		if err != nil || due.IsZero() {
+			continue
+		}
+		subject := ""
+		if t.Subject != nil {
+			subject = *t.Subject
+		}
+		if p := parents[t]; p != nil {
+			if parentDue, err := parseDate(p.DueDate); err == nil && !parentDue.IsZero() && due.After(parentDue) {
+				report.Late = append(report.Late, &LateTicket{t.ID, subject, *t.DueDate, fmt.Sprintf("parent #%d", p.ID), *p.DueDate})
+			}
+		}
+		if v := issueVersions[t.ID]; v != nil && v.DueDate != "" && *t.DueDate > v.DueDate {
+			report.Late = append(report.Late, &LateTicket{t.ID, subject, *t.DueDate, "version " + v.Name, v.DueDate})
+		}
+	}
+	sort.SliceStable(report.Tickets, func(i, j int) bool {
+		return report.Tickets[i].Slack < report.Tickets[j].Slack
+	})
+	return report, nil
+}
+
+func newTicketSlack(t *Ticket, slack int) *TicketSlack {
+	s := &TicketSlack{ID: t.ID, Slack: slack, Critical: slack <= 0}
+	if t.Subject != nil {
+		s.Subject = *t.Subject
+	}
+	if t.StartDate != nil {
+		s.StartDate = *t.StartDate
+	}
+	if t.DueDate != nil {
+		s.DueDate = *t.DueDate
+	}
+	return s
+}
+
+// workdaysBetween returns the working days from the date to the date, which is negative if to is before from.
+func (c *Converter) workdaysBetween(from time.Time, to time.Time) int {
+	step := 1
+	if to.Before(from) {
+		from, to, step = to, from, -1
+	}
+	n := 0
+	for d := from; d.Before(to); {
+		d = d.AddDate(0, 0, 1)
+		if c.isWorkday(d) {
+			n++
+		}
+	}
+	return n * step
+}
+
+// WriteText writes the report for humans.
+func (r *CriticalPathReport) WriteText(w io.Writer) error {
+	line := func(s *TicketSlack) string {
+		return fmt.Sprintf("#%d %s (%s..%s)", s.ID, s.Subject, s.StartDate, s.DueDate)
+	}
+	fmt.Fprintln(w, "Critical path:")
+	for _, s := range r.CriticalPath {
+		fmt.Fprintf(w, "  %s\n", line(s))
+	}
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Total slack (working days):")
+	for _, s := range r.Tickets {
+		mark := ""
+		if s.Critical {
+			mark = " critical"
+		}
+		fmt.Fprintf(w, "  %4d%s %s\n", s.Slack, mark, line(s))
+	}
+	if len(r.Late) > 0 {
+		fmt.Fprintln(w, "")
+		fmt.Fprintln(w, "Late:")
+		for _, l := range r.Late {
+			fmt.Fprintf(w, "  #%d %s is due %s after %s due %s\n", l.ID, l.Subject, l.DueDate, l.Limit, l.LimitDueDate)
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the report as JSON.
+func (r *CriticalPathReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
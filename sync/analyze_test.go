@@ -0,0 +1,202 @@
+package sync
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	redmine "github.com/uphy/go-redmine"
+)
+
+// datedTicket returns the ticket of the dates in YYYY-MM-DD.
+func datedTicket(id int, start string, due string, children ...*Ticket) *Ticket {
+	t := &Ticket{ID: id, Subject: stringPtr(fmt.Sprintf("t%d", id)), Children: children}
+	if start != "" {
+		t.StartDate = stringPtr(start)
+	}
+	if due != "" {
+		t.DueDate = stringPtr(due)
+	}
+	return t
+}
+
+func TestAnalyzeCriticalPath(t *testing.T) {
+	delay := func(days int) *int {
+		return &days
+	}
+	// 2024-06-10 is Monday
+	tests := []struct {
+		name      string
+		tickets   []*Ticket
+		relations []Relation
+		versions  map[int]*redmine.Version
+		path      []int
+		slacks    map[int]int
+		late      []string
+	}{
+		{
+			"precedes and blocks",
+			[]*Ticket{
+				datedTicket(1, "2024-06-10", "2024-06-11"),
+				datedTicket(2, "2024-06-12", "2024-06-14"),
+				datedTicket(3, "2024-06-10", "2024-06-10"),
+				datedTicket(4, "2024-06-10", "2024-06-12"),
+			},
+			[]Relation{
+				{ID: 1, IssueID: 1, IssueToID: 2, RelationType: RelationPrecedes},
+				{ID: 2, IssueID: 3, IssueToID: 2, RelationType: RelationBlocks},
+				{ID: 3, IssueID: 4, IssueToID: 2, RelationType: RelationRelates},
+			},
+			nil,
+			[]int{1, 2},
+			map[int]int{1: 0, 2: 0, 3: 1, 4: 2},
+			[]string{},
+		},
+		{
+			"follows with the delay",
+			[]*Ticket{
+				datedTicket(1, "2024-06-10", "2024-06-10"),
+				datedTicket(2, "2024-06-13", "2024-06-14"),
+			},
+			[]Relation{{ID: 1, IssueID: 2, IssueToID: 1, RelationType: RelationFollows, Delay: delay(1)}},
+			nil,
+			[]int{1, 2},
+			map[int]int{1: 1, 2: 0},
+			[]string{},
+		},
+		{
+			"over the weekend",
+			[]*Ticket{
+				datedTicket(1, "2024-06-13", "2024-06-14"),
+				datedTicket(2, "2024-06-17", "2024-06-18"),
+			},
+			[]Relation{{ID: 1, IssueID: 1, IssueToID: 2, RelationType: RelationPrecedes}},
+			nil,
+			[]int{1, 2},
+			map[int]int{1: 0, 2: 0},
+			[]string{},
+		},
+		{
+			"relations of the parent",
+			[]*Ticket{
+				datedTicket(1, "2024-06-10", "2024-06-10"),
+				datedTicket(2, "", "", datedTicket(3, "2024-06-11", "2024-06-11"), datedTicket(4, "2024-06-11", "2024-06-12")),
+			},
+			[]Relation{{ID: 1, IssueID: 1, IssueToID: 2, RelationType: RelationPrecedes}},
+			nil,
+			[]int{1, 4},
+			map[int]int{1: 0, 2: 0, 3: 1, 4: 0},
+			[]string{},
+		},
+		{
+			"due dates of the parent and the version",
+			[]*Ticket{
+				datedTicket(1, "2024-06-10", "2024-06-14"),
+				datedTicket(2, "", "2024-06-12", datedTicket(3, "2024-06-10", "2024-06-11"), datedTicket(4, "2024-06-10", "2024-06-13")),
+			},
+			nil,
+			map[int]*redmine.Version{1: {Name: "v1", DueDate: "2024-06-13"}, 3: {Name: "v2", DueDate: "2024-06-11"}},
+			[]int{1},
+			map[int]int{1: 0, 2: -1, 3: 1, 4: -1},
+			[]string{"#1 version v1 2024-06-13", "#4 parent #2 2024-06-12"},
+		},
+	}
+	for _, test := range tests {
+		c := testConverter()
+		c.relations = func(issueID int) ([]Relation, error) {
+			relations := []Relation{}
+			for _, r := range test.relations {
+				if r.IssueID == issueID || r.IssueToID == issueID {
+					relations = append(relations, r)
+				}
+			}
+			return relations, nil
+		}
+		config := &Config{Projects: []*Project{{ID: 1, Tickets: test.tickets}}}
+		report, err := c.AnalyzeCriticalPath(config, test.versions)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		path := []int{}
+		for _, s := range report.CriticalPath {
+			path = append(path, s.ID)
+		}
+		if !reflect.DeepEqual(path, test.path) {
+			t.Errorf("%s: critical path: want %v, got %v", test.name, test.path, path)
+		}
+		slacks := map[int]int{}
+		for _, s := range report.Tickets {
+			slacks[s.ID] = s.Slack
+			if s.Critical != (s.Slack <= 0) {
+				t.Errorf("%s: #%d: critical with the slack %d", test.name, s.ID, s.Slack)
+			}
+		}
+		if !reflect.DeepEqual(slacks, test.slacks) {
+			t.Errorf("%s: slacks: want %v, got %v", test.name, test.slacks, slacks)
+		}
+		for i := 1; i < len(report.Tickets); i++ {
+			if report.Tickets[i-1].Slack > report.Tickets[i].Slack {
+				t.Errorf("%s: tickets not sorted by the slacks", test.name)
+			}
+		}
+		late := []string{}
+		for _, l := range report.Late {
+			late = append(late, fmt.Sprintf("#%d %s %s", l.ID, l.Limit, l.LimitDueDate))
+		}
+		if !reflect.DeepEqual(late, test.late) {
+			t.Errorf("%s: late: want %v, got %v", test.name, test.late, late)
+		}
+	}
+}
+
+func TestAnalyzeCriticalPathCycle(t *testing.T) {
+	c := testConverter()
+	relations := []Relation{
+		{ID: 1, IssueID: 1, IssueToID: 2, RelationType: RelationPrecedes},
+		{ID: 2, IssueID: 2, IssueToID: 3, RelationType: RelationBlocks},
+		{ID: 3, IssueID: 1, IssueToID: 3, RelationType: RelationFollows},
+	}
+	c.relations = func(issueID int) ([]Relation, error) {
+		return relations, nil
+	}
+	config := &Config{Projects: []*Project{{ID: 1, Tickets: []*Ticket{
+		datedTicket(1, "2024-06-10", "2024-06-10"),
+		datedTicket(2, "2024-06-11", "2024-06-11"),
+		datedTicket(3, "2024-06-12", "2024-06-12"),
+	}}}}
+	if _, err := c.AnalyzeCriticalPath(config, nil); err == nil || !strings.Contains(err.Error(), "dependency cycle") {
+		t.Errorf("want the dependency cycle error, got %v", err)
+	}
+}
+
+func TestSyncAnalyzeCriticalPath(t *testing.T) {
+	c := testConverter()
+	c.relations = func(issueID int) ([]Relation, error) {
+		return nil, nil
+	}
+	c.versions = func(projectID int) ([]redmine.Version, error) {
+		return []redmine.Version{{Id: 9, Name: "v1", DueDate: "2024-06-10"}}, nil
+	}
+	var fetched []int
+	c.issues = func(ids []int) ([]redmine.Issue, error) {
+		fetched = ids
+		return []redmine.Issue{{Id: 1, FixedVersion: &redmine.IdName{Id: 9}}}, nil
+	}
+	s := &Sync{Converter: c}
+	config := &Config{Projects: []*Project{{ID: 1, Tickets: []*Ticket{
+		datedTicket(1, "2024-06-10", "2024-06-11", datedTicket(2, "2024-06-10", "2024-06-10")),
+		datedTicket(0, "2024-06-10", "2024-06-10"),
+	}}}}
+	report, err := s.AnalyzeCriticalPath(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// only the issues of the config are fetched for the fixed versions
+	if !reflect.DeepEqual(fetched, []int{1, 2}) {
+		t.Errorf("want the issues 1 and 2 fetched, got %v", fetched)
+	}
+	if len(report.Late) != 1 || report.Late[0].Limit != "version v1" {
+		t.Errorf("want #1 late for the version, got %+v", report.Late)
+	}
+}
@@ -0,0 +1,128 @@
+package sync
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	redmine "github.com/uphy/go-redmine"
+)
+
+// issueBatchSize is the number of the issues fetched by a request, which is the maximum limit of the API.
+const issueBatchSize = 100
+
+// apiError is the error response of the API.
+type apiError struct {
+	Path       string
+	StatusCode int
+	Status     string
+	Errors     []string
+}
+
+func (e *apiError) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("%s: %s", e.Path, e.Status)
+	}
+	return strings.Join(e.Errors, "\n")
+}
+
+// getJSON decodes the JSON response of the API path with the parameters.
+// The API calls not covered by the client are made with it.
+func getJSON(client *redmine.Client, endpoint string, apiKey string, path string, v interface{}) error {
+	res, err := client.Get(apiURL(endpoint, apiKey, path))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != 200 {
+		return responseError(path, res)
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// putJSON sends the value as the JSON body to the API path.
+func putJSON(client *redmine.Client, endpoint string, apiKey string, path string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("PUT", apiURL(endpoint, apiKey, path), bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	// Redmine responds with 204 No Content or 200 OK depending on the version
+	if res.StatusCode != 200 && res.StatusCode != 204 {
+		return responseError(path, res)
+	}
+	return nil
+}
+
+func apiURL(endpoint string, apiKey string, path string) string {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return strings.TrimRight(endpoint, "/") + path + sep + "key=" + apiKey
+}
+
+// responseError returns the error of the response, with the error messages of the API if any.
+func responseError(path string, res *http.Response) error {
+	e := &apiError{Path: path, StatusCode: res.StatusCode, Status: res.Status}
+	if res.StatusCode != 404 {
+		var er struct {
+			Errors []string `json:"errors"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&er); err == nil {
+			e.Errors = er.Errors
+		}
+	}
+	return e
+}
+
+// get decodes the JSON response of the API path with the parameters.
+func (s *Sync) get(path string, v interface{}) error {
+	return getJSON(s.client, s.endpoint, s.apiKey, path, v)
+}
+
+// put sends the value as the JSON body to the API path.
+func (s *Sync) put(path string, v interface{}) error {
+	return putJSON(s.client, s.endpoint, s.apiKey, path, v)
+}
+
+// issuesByIDs returns the function fetching the issues of the IDs including the closed ones in batches.
+// The deleted issues and the issues the user can't see are not in the result.
+func issuesByIDs(client *redmine.Client, endpoint string, apiKey string) func(ids []int) ([]redmine.Issue, error) {
+	return func(ids []int) ([]redmine.Issue, error) {
+		issues := []redmine.Issue{}
+		for i := 0; i < len(ids); i += issueBatchSize {
+			end := i + issueBatchSize
+			if end > len(ids) {
+				end = len(ids)
+			}
+			batch := []string{}
+			for _, id := range ids[i:end] {
+				batch = append(batch, strconv.Itoa(id))
+			}
+			var r struct {
+				Issues []redmine.Issue `json:"issues"`
+			}
+			params := "issue_id=" + strings.Join(batch, ",") + "&status_id=*&limit=" + strconv.Itoa(issueBatchSize)
+			if err := getJSON(client, endpoint, apiKey, "/issues.json?"+params, &r); err != nil {
+				return nil, err
+			}
+			issues = append(issues, r.Issues...)
+		}
+		return issues, nil
+	}
+}
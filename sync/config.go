@@ -2,33 +2,41 @@ package sync
 
 type (
 	Config struct {
-		Projects []*Project `yaml:"projects"`
+		Projects []*Project `yaml:"projects" json:"projects"`
 	}
 
 	Project struct {
-		ID      int       `yaml:"id"`
-		Tickets []*Ticket `yaml:"tickets"`
+		ID      int       `yaml:"id" json:"id"`
+		Tickets []*Ticket `yaml:"tickets" json:"tickets"`
 	}
 
 	// 変更可能な項目を定義。この構造体に含まれないフィールドについては更新されない。
 	// []Issue => Config => []Ticket => Redmine
 	// YAML =ReadConfigYAML=> Config => []Ticket => Redmine
+	// JSON =ReadConfigJSON=> Config => []Ticket => Redmine
 	// CSV =ReadConfigCSV=> Config
 	Ticket struct {
-		Project     *string `yaml:"-" csv:"Project"`
-		ID          int     `yaml:"id" csv:"ID"`
-		ParentID    int     `yaml:"-" csv:"Parent ID"`
-		Subject     *string `yaml:"subject" csv:"Subject"`
-		Assignee    *string `yaml:"assignee" csv:"Assignee"`
-		Status      *string `yaml:"status" csv:"Status"`
-		DoneRatio   *int    `yaml:"done_ratio" csv:"Done Ratio"`
-		Description *string `yaml:"description" csv:"Description"`
-		Tracker     *string `yaml:"tracker" csv:"Tracker"`
-		StartDate   *string `yaml:"start_date" csv:"Start Date"`
-		DueDate     *string `yaml:"due_date" csv:"Due Date"`
-		Priority    *string `yaml:"priority" csv:"Priority"`
+		Project     *string `yaml:"-" csv:"Project" json:"-"`
+		ID          int     `yaml:"id" csv:"ID" json:"id"`
+		ParentID    int     `yaml:"-" csv:"Parent ID" json:"-"`
+		Subject     *string `yaml:"subject" csv:"Subject" json:"subject"`
+		Assignee    *string `yaml:"assignee" csv:"Assignee" json:"assignee"`
+		Status      *string `yaml:"status" csv:"Status" json:"status"`
+		DoneRatio   *int    `yaml:"done_ratio" csv:"Done Ratio" json:"done_ratio"`
+		Description *string `yaml:"description" csv:"Description" json:"description"`
+		Tracker     *string `yaml:"tracker" csv:"Tracker" json:"tracker"`
+		StartDate   *string `yaml:"start_date" csv:"Start Date" json:"start_date"`
+		DueDate     *string `yaml:"due_date" csv:"Due Date" json:"due_date"`
+		Priority    *string `yaml:"priority" csv:"Priority" json:"priority"`
+		// Duration is the working days of the ticket used by the scheduling, which is not synced with Redmine.
+		Duration *int `yaml:"duration,omitempty" csv:"Duration" json:"duration,omitempty"`
 
-		Children []*Ticket `yaml:"children,omitempty" csv:"-"`
+		Children []*Ticket `yaml:"children,omitempty" csv:"-" json:"children,omitempty"`
+
+		// path is the file of the ticket read from a directory
+		path string
+		// line is the line of the ticket read from a CSV or TSV file
+		line int
 	}
 )
 
@@ -1,11 +1,14 @@
 package sync
 
 import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
-	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
@@ -17,15 +20,55 @@ import (
 
 type (
 	Converter struct {
-		Trackers   *Names
-		Priorities *Names
-		Projects   *Names
-		Statuses   *Names
-		Users      *Names
+		Trackers       *Names
+		Priorities     *Names
+		Projects       *Names
+		Statuses       *Names
+		ClosedStatuses *Names
+		Users          *Names
+		// ClosedStatus is the status set to the checked tickets of the markdown format.
+		ClosedStatus string
+		// OpenStatus is the status set to the unchecked tickets of the markdown format which have the closed status.
+		// Defaults to the first open status.
+		OpenStatus string
+		// CSVEncoding is the encoding of the written CSV files: utf-8, utf-8-bom or shift_jis.
+		// The encoding of the read files is detected automatically.
+		CSVEncoding string
+		// CSVDelimiter is the field delimiter of the CSV files. Defaults to comma.
+		CSVDelimiter rune
+		// CalendarAssignee restricts the tickets of the iCalendar feed to the assignee if not empty.
+		CalendarAssignee string
+		// CalendarTodo writes the tickets of the iCalendar feed as VTODO instead of VEVENT.
+		CalendarTodo bool
+
+		// GraphRelations draws the issue relations in the DOT graph, which requires a request per ticket.
+		GraphRelations bool
+
+		// StreamFormat is the format name of the config read from the stdin or written to the stdout, which
+		// don't have the file extensions. The format of the files is always detected from the extensions.
+		StreamFormat string
+
+		// Holidays are the non-working days of YYYY-MM-DD in addition to the weekends.
+		Holidays map[string]bool
+
+		// Transforms are the names of the transform plugins applied to the config before importing.
+		Transforms []string
+
+		// parentCycle is called with the first ticket of each parent cycle in the CSV files and the directories,
+		// which is read as a root ticket to read the rest, instead of the error if not nil.
+		parentCycle func(t *Ticket, cycle string)
+
+		endpoint  string
+		versions  func(projectID int) ([]redmine.Version, error)
+		relations func(issueID int) ([]Relation, error)
+		issues    func(ids []int) ([]redmine.Issue, error)
 	}
 	Names struct {
 		names []redmine.IdName
 		init  func() ([]redmine.IdName, error)
+		// placeholder names the unknown IDs as "#ID", and gives the negative IDs to the unknown names, instead of
+		// the errors, to read the files with the unknown names.
+		placeholder bool
 	}
 )
 
@@ -40,6 +83,17 @@ func (t *Names) initIfNeeded() error {
 	return nil
 }
 
+func (t *Names) list() ([]string, error) {
+	if err := t.initIfNeeded(); err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, n := range t.names {
+		names = append(names, n.Name)
+	}
+	return names, nil
+}
+
 func (t *Names) FindNameByID(id int) (string, error) {
 	if err := t.initIfNeeded(); err != nil {
 		return "", err
@@ -49,6 +103,11 @@ func (t *Names) FindNameByID(id int) (string, error) {
 			return n.Name, nil
 		}
 	}
+	if t.placeholder {
+		name := "#" + strconv.Itoa(id)
+		t.names = append(t.names, redmine.IdName{Id: id, Name: name})
+		return name, nil
+	}
 	return "", fmt.Errorf("no such id: %d", id)
 }
 
@@ -69,6 +128,16 @@ func (t *Names) FindIDByName(name string) (int, error) {
 			}
 		}
 	}
+	if t.placeholder {
+		id := -1
+		for _, n := range t.names {
+			if n.Id <= id {
+				id = n.Id - 1
+			}
+		}
+		t.names = append(t.names, redmine.IdName{Id: id, Name: name})
+		return id, nil
+	}
 
 	names := []string{}
 	for _, n := range t.names {
@@ -77,10 +146,10 @@ func (t *Names) FindIDByName(name string) (int, error) {
 	return 0, fmt.Errorf("no such name: %s, available names: %v", name, names)
 }
 
-func newConverter(client *redmine.Client) *Converter {
+func newConverter(client *redmine.Client, endpoint string, apiKey string) *Converter {
 	return &Converter{
-		Trackers: &Names{nil, client.Trackers},
-		Priorities: &Names{nil, func() ([]redmine.IdName, error) {
+		Trackers: &Names{init: client.Trackers},
+		Priorities: &Names{init: func() ([]redmine.IdName, error) {
 			list, err := client.IssuePriorities()
 			if err != nil {
 				return nil, err
@@ -95,7 +164,7 @@ func newConverter(client *redmine.Client) *Converter {
 			}
 			return names, nil
 		}},
-		Projects: &Names{nil, func() ([]redmine.IdName, error) {
+		Projects: &Names{init: func() ([]redmine.IdName, error) {
 			list, err := client.Projects()
 			if err != nil {
 				return nil, err
@@ -110,7 +179,7 @@ func newConverter(client *redmine.Client) *Converter {
 			}
 			return names, nil
 		}},
-		Statuses: &Names{nil, func() ([]redmine.IdName, error) {
+		Statuses: &Names{init: func() ([]redmine.IdName, error) {
 			list, err := client.IssueStatuses()
 			if err != nil {
 				return nil, err
@@ -125,7 +194,24 @@ func newConverter(client *redmine.Client) *Converter {
 			}
 			return names, nil
 		}},
-		Users: &Names{nil, func() ([]redmine.IdName, error) {
+		ClosedStatuses: &Names{init: func() ([]redmine.IdName, error) {
+			list, err := client.IssueStatuses()
+			if err != nil {
+				return nil, err
+			}
+
+			names := []redmine.IdName{}
+			for _, item := range list {
+				if item.IsClosed {
+					names = append(names, redmine.IdName{
+						Id:   item.Id,
+						Name: item.Name,
+					})
+				}
+			}
+			return names, nil
+		}},
+		Users: &Names{init: func() ([]redmine.IdName, error) {
 			list, err := client.Users()
 			if err != nil {
 				return nil, err
@@ -140,7 +226,25 @@ func newConverter(client *redmine.Client) *Converter {
 			}
 			return names, nil
 		}},
+		endpoint:  endpoint,
+		versions:  client.Versions,
+		relations: issueRelations(client, endpoint, apiKey),
+		issues:    issuesByIDs(client, endpoint, apiKey),
+	}
+}
+
+// IssueURL returns the URL of the issue page.
+func (c *Converter) IssueURL(id int) string {
+	return strings.TrimRight(c.endpoint, "/") + "/issues/" + strconv.Itoa(id)
+}
+
+// isClosed reports whether the status is one of the closed statuses.
+func (c *Converter) isClosed(status *string) bool {
+	if status == nil {
+		return false
 	}
+	_, err := c.ClosedStatuses.FindIDByName(*status)
+	return err == nil
 }
 
 func (c *Converter) Convert(issues []redmine.Issue) (*Config, error) {
@@ -260,39 +364,117 @@ func (c *Converter) mergeTicketToIssue(src *Ticket, dst *redmine.Issue) error {
 	return nil
 }
 
-func (c *Converter) ReadConfig(file *os.File) (*Config, error) {
-	ext := c.extension(file.Name())
-	switch ext {
-	case ".yaml", ".yml":
-		return c.readConfigYAML(file)
-	case ".csv", "":
-		return c.readConfigCSV(file)
-	default:
-		return nil, errors.New("unsupported extension: " + ext)
+// ReadConfig reads the config in the format of the file extension if the reader is a file, or in StreamFormat.
+func (c *Converter) ReadConfig(reader io.Reader) (*Config, error) {
+	if file, ok := reader.(*os.File); ok {
+		if info, err := file.Stat(); err == nil && info.IsDir() {
+			return c.readConfigDir(file.Name())
+		}
+	}
+	format, err := c.formatOf(reader)
+	if err != nil {
+		return nil, err
 	}
+	return format.Read(c, reader)
 }
 
-func (c *Converter) SaveConfig(file *os.File, config *Config) error {
-	ext := c.extension(file.Name())
-	switch ext {
-	case ".yaml", ".yml":
-		return c.SaveConfigYAML(file, config)
-	case ".csv", "":
-		return c.SaveConfigCSV(file, config)
-	default:
-		return errors.New("unsupported extension: " + ext)
+// SaveConfigFile writes the config to the file or the directory of the name.
+// An existing YAML file of the config is patched with the created IDs to keep the comments and the layout.
+// The file is left untouched and an error is returned if it doesn't match the config, for example when it is edited
+// during the import.
+func (c *Converter) SaveConfigFile(name string, config *Config) error {
+	if isDir(name) {
+		return c.SaveConfigDir(name, config)
+	}
+	if c.isYAMLFile(name) {
+		if src, err := ioutil.ReadFile(name); err == nil {
+			patched, ok, err := patchYAML(src, config)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return fmt.Errorf("%s doesn't match the imported config, the IDs of the created issues and the dates are not written back", name)
+			}
+			return ioutil.WriteFile(name, patched, 0644)
+		}
 	}
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return c.SaveConfig(f, config)
 }
 
-func (c *Converter) extension(name string) string {
-	return strings.ToLower(filepath.Ext(name))
+// SaveConfigFileAs writes the config to the file or the directory of the name in the format.
+func (c *Converter) SaveConfigFileAs(name string, format Format, config *Config) error {
+	if f, ok := format.(pathFormat); ok {
+		return f.writePath(c, name, config)
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return format.Write(c, f, config)
+}
+
+// SaveConfig writes the config in the format of the file extension if the writer is a file, or in StreamFormat.
+func (c *Converter) SaveConfig(writer io.Writer, config *Config) error {
+	if file, ok := writer.(*os.File); ok {
+		if info, err := file.Stat(); err == nil && info.IsDir() {
+			return c.SaveConfigDir(file.Name(), config)
+		}
+	}
+	format, err := c.formatOf(writer)
+	if err != nil {
+		return err
+	}
+	return format.Write(c, writer, config)
+}
+
+// formatOf returns the registered format of the file extension, or the format of StreamFormat for the other streams.
+// The file without an extension is CSV.
+func (c *Converter) formatOf(stream interface{}) (Format, error) {
+	if file, ok := stream.(*os.File); ok && file != os.Stdin && file != os.Stdout {
+		return FormatOfFile(file.Name())
+	}
+	if c.StreamFormat == "" {
+		return nil, errors.New("format of the stream is unknown; specify the format")
+	}
+	return FindFormat(c.StreamFormat)
 }
 
 func (c *Converter) readConfigCSV(reader io.Reader) (*Config, error) {
+	return c.readConfigDelimited(reader, c.csvDelimiter())
+}
+
+func (c *Converter) readConfigTSV(reader io.Reader) (*Config, error) {
+	return c.readConfigDelimited(reader, '\t')
+}
+
+func (c *Converter) readConfigDelimited(reader io.Reader, delimiter rune) (*Config, error) {
+	decoded, err := decodeCSV(reader)
+	if err != nil {
+		return nil, err
+	}
+	b, err := ioutil.ReadAll(decoded)
+	if err != nil {
+		return nil, err
+	}
+	r := csv.NewReader(bytes.NewReader(b))
+	r.Comma = delimiter
 	var csvTickets []*Ticket
-	if err := gocsv.Unmarshal(reader, &csvTickets); err != nil {
+	if err := gocsv.UnmarshalCSV(r, &csvTickets); err != nil {
 		return nil, err
 	}
+	// the first record is the header
+	lines := csvRecordLines(b)
+	for i, t := range csvTickets {
+		if i+1 < len(lines) {
+			t.line = lines[i+1]
+		}
+	}
 	return c.toHierarchical(csvTickets)
 }
 
@@ -308,13 +490,45 @@ func (c *Converter) readConfigYAML(reader io.Reader) (*Config, error) {
 	return &config, nil
 }
 
+func (c *Converter) readConfigJSON(reader io.Reader) (*Config, error) {
+	var config Config
+	decoder := json.NewDecoder(reader)
+	if err := decoder.Decode(&config); err != nil {
+		return nil, err
+	}
+	if _, err := c.toFlat(&config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func (c *Converter) SaveConfigJSON(writer io.Writer, config *Config) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(config)
+}
+
 func (c *Converter) SaveConfigYAML(writer io.Writer, config *Config) error {
 	encoder := yaml.NewEncoder(writer)
 	return encoder.Encode(config)
 }
 
 func (c *Converter) SaveConfigCSV(writer io.Writer, config *Config) error {
-	w := gocsv.DefaultCSVWriter(writer)
+	return c.saveConfigDelimited(writer, config, c.csvDelimiter())
+}
+
+func (c *Converter) SaveConfigTSV(writer io.Writer, config *Config) error {
+	return c.saveConfigDelimited(writer, config, '\t')
+}
+
+func (c *Converter) saveConfigDelimited(writer io.Writer, config *Config, delimiter rune) error {
+	encoded, err := c.encodeCSV(writer)
+	if err != nil {
+		return err
+	}
+	csvWriter := csv.NewWriter(encoded)
+	csvWriter.Comma = delimiter
+	w := gocsv.NewSafeCSVWriter(csvWriter)
 	tickets, err := c.toFlat(config)
 	if err != nil {
 		return err
@@ -330,7 +544,7 @@ func (c *Converter) SaveConfigCSV(writer io.Writer, config *Config) error {
 			}
 		}
 	}
-	return nil
+	return encoded.Close()
 }
 
 func (c *Converter) toFlat(config *Config) ([]*Ticket, error) {
@@ -345,7 +559,8 @@ func (c *Converter) toFlat(config *Config) ([]*Ticket, error) {
 			tickets = c.collectTickets(tickets, ticket, projectName)
 		}
 	}
-	sort.Slice(tickets, func(i, j int) bool {
+	// stable not to create the new children before their new parents
+	sort.SliceStable(tickets, func(i, j int) bool {
 		p1 := *tickets[i].Project
 		p2 := *tickets[j].Project
 		c := strings.Compare(p1, p2)
@@ -374,8 +589,25 @@ func (c *Converter) collectTickets(tickets []*Ticket, t *Ticket, projectName str
 
 func (c *Converter) toHierarchical(tickets []*Ticket) (*Config, error) {
 	idToTickets := map[int]*Ticket{}
+	parents := map[int]int{}
 	for _, t := range tickets {
 		idToTickets[t.ID] = t
+		if t.ID != 0 {
+			parents[t.ID] = t.ParentID
+		}
+	}
+	// the tickets in a cycle would never reach the project
+	name := func(id int) string {
+		return "#" + strconv.Itoa(id)
+	}
+	cycles := parentCycles(parents)
+	if len(cycles) > 0 && c.parentCycle == nil {
+		return nil, fmt.Errorf("parent cycle: %s", formatParentCycles(cycles, name))
+	}
+	for _, cycle := range cycles {
+		t := idToTickets[cycle[0]]
+		c.parentCycle(t, formatParentCycles([][]int{cycle}, name))
+		t.ParentID = 0
 	}
 
 	config := &Config{}
@@ -417,3 +649,42 @@ func (c *Converter) toHierarchical(tickets []*Ticket) (*Config, error) {
 	})
 	return config, nil
 }
+
+// parentCycles returns the cycles in the parents of the keys, each of which is the path from a key back to itself.
+func parentCycles(parents map[int]int) [][]int {
+	ids := []int{}
+	for id := range parents {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	inCycle := map[int]bool{}
+	cycles := [][]int{}
+	for _, id := range ids {
+		path := []int{id}
+		for p := parents[id]; p != 0 && !inCycle[p] && len(path) <= len(parents); p = parents[p] {
+			path = append(path, p)
+			if p != id {
+				continue
+			}
+			for _, i := range path {
+				inCycle[i] = true
+			}
+			cycles = append(cycles, path)
+			break
+		}
+	}
+	return cycles
+}
+
+// formatParentCycles returns the cycles of the keys with the names of the keys.
+func formatParentCycles(cycles [][]int, name func(key int) string) string {
+	list := []string{}
+	for _, cycle := range cycles {
+		names := []string{}
+		for _, key := range cycle {
+			names = append(names, name(key))
+		}
+		list = append(list, strings.Join(names, " -> "))
+	}
+	return strings.Join(list, ", ")
+}
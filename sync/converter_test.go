@@ -0,0 +1,177 @@
+package sync
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+
+	redmine "github.com/uphy/go-redmine"
+)
+
+// testConverter returns the converter of the fixed names, which doesn't call the server.
+func testConverter() *Converter {
+	names := func(list ...redmine.IdName) *Names {
+		return &Names{names: list}
+	}
+	return &Converter{
+		Projects:       names(redmine.IdName{Id: 1, Name: "aaaa"}, redmine.IdName{Id: 2, Name: "a/b: c"}),
+		Trackers:       names(redmine.IdName{Id: 1, Name: "Bug"}, redmine.IdName{Id: 2, Name: "Feature"}),
+		Priorities:     names(redmine.IdName{Id: 2, Name: "Normal"}),
+		Statuses:       names(redmine.IdName{Id: 1, Name: "New"}, redmine.IdName{Id: 2, Name: "In Progress"}, redmine.IdName{Id: 5, Name: "Closed"}),
+		ClosedStatuses: names(redmine.IdName{Id: 5, Name: "Closed"}),
+		Users:          names(redmine.IdName{Id: 7, Name: "Alice A"}),
+		ClosedStatus:   "Closed",
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+// assertConfig fails if the configs differ in the synced fields, the durations and the hierarchy.
+func assertConfig(t *testing.T, name string, want *Config, got *Config) {
+	t.Helper()
+	w, g := dumpConfig(want), dumpConfig(got)
+	if !reflect.DeepEqual(w, g) {
+		t.Errorf("%s: configs differ\nwant: %q\n got: %q", name, w, g)
+	}
+}
+
+// dumpConfig returns the tickets as the lines of the fields.
+func dumpConfig(config *Config) []string {
+	lines := []string{}
+	var dump func(project int, parent int, tickets []*Ticket)
+	dump = func(project int, parent int, tickets []*Ticket) {
+		for _, t := range tickets {
+			line := fmt.Sprintf("project=%d id=%d parent=%d", project, t.ID, parent)
+			fields := ticketFields(t)
+			duration := ticketField{"duration", nil}
+			if t.Duration != nil {
+				duration.value = stringPtr(strconv.Itoa(*t.Duration))
+			}
+			fields = append(fields, duration)
+			for _, f := range fields {
+				if f.name == "project" || f.name == "parent" {
+					continue
+				}
+				if f.value == nil {
+					line += " " + f.name + "=<nil>"
+				} else {
+					line += fmt.Sprintf(" %s=%q", f.name, *f.value)
+				}
+			}
+			lines = append(lines, line)
+			dump(project, t.ID, t.Children)
+		}
+	}
+	for _, p := range config.Projects {
+		dump(p.ID, 0, p.Tickets)
+	}
+	return lines
+}
+
+func TestFormatOf(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	file, err := os.Create(filepath.Join(dir, "last.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	c := testConverter()
+	c.StreamFormat = "json"
+	tests := []struct {
+		stream interface{}
+		format string
+	}{
+		{os.Stdin, "json"},
+		{os.Stdout, "json"},
+		{&bytes.Buffer{}, "json"},
+		// the stream format is not applied to the files
+		{file, "yaml"},
+	}
+	for _, test := range tests {
+		format, err := c.formatOf(test.stream)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if format.Name() != test.format {
+			t.Errorf("%T: want %s, got %s", test.stream, test.format, format.Name())
+		}
+	}
+	c.StreamFormat = ""
+	if _, err := c.formatOf(os.Stdin); err == nil {
+		t.Error("want the error of the unknown format of the stdin")
+	}
+}
+
+func TestSaveConfigFileAsDir(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	format, err := FindFormat("dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := testConverter()
+	config := &Config{Projects: []*Project{{ID: 1, Tickets: []*Ticket{{ID: 5, Subject: stringPtr("a"), Description: stringPtr("")}}}}}
+	if err := c.SaveConfigFileAs(dir, format, config); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got, err := format.Read(c, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertConfig(t, "dir", config, got)
+	if err := format.Write(c, &bytes.Buffer{}, config); err == nil {
+		t.Error("want the error of the dir format without a directory")
+	}
+}
+
+func TestSaveConfigFileYAML(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	c := testConverter()
+	c.StreamFormat = "yaml"
+	name := filepath.Join(dir, "issues.yml")
+	src := "# comment\nprojects:\n- id: 1\n  tickets:\n  - subject: a\n"
+	tests := []struct {
+		name    string
+		subject string
+		want    string
+		err     bool
+	}{
+		{"patched", "a", "# comment\nprojects:\n- id: 1\n  tickets:\n  - id: 5\n    subject: a\n", false},
+		{"changed", "b", src, true},
+	}
+	for _, test := range tests {
+		if err := ioutil.WriteFile(name, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		config := &Config{Projects: []*Project{{ID: 1, Tickets: []*Ticket{{ID: 5, Subject: stringPtr(test.subject)}}}}}
+		if err := c.SaveConfigFile(name, config); (err != nil) != test.err {
+			t.Errorf("%s: error: want %v, got %v", test.name, test.err, err)
+		}
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != test.want {
+			t.Errorf("%s: want\n%s\ngot\n%s", test.name, test.want, b)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package sync
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/transform"
+)
+
+const (
+	EncodingUTF8     = "utf-8"
+	EncodingUTF8BOM  = "utf-8-bom"
+	EncodingShiftJIS = "shift_jis"
+)
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+func (c *Converter) csvDelimiter() rune {
+	if c.CSVDelimiter == 0 {
+		return ','
+	}
+	return c.CSVDelimiter
+}
+
+// encodeCSV wraps the writer to encode the CSV with the configured encoding.
+// The returned writer must be closed to flush the encoded bytes.
+func (c *Converter) encodeCSV(writer io.Writer) (io.WriteCloser, error) {
+	switch c.CSVEncoding {
+	case "", EncodingUTF8:
+		return nopWriteCloser{writer}, nil
+	case EncodingUTF8BOM:
+		if _, err := writer.Write(utf8BOM); err != nil {
+			return nil, err
+		}
+		return nopWriteCloser{writer}, nil
+	case EncodingShiftJIS:
+		return transform.NewWriter(writer, japanese.ShiftJIS.NewEncoder()), nil
+	default:
+		return nil, errors.New("unsupported encoding: " + c.CSVEncoding)
+	}
+}
+
+// decodeCSV detects the encoding of the CSV and returns the reader decoding it to UTF-8.
+// UTF-8 with or without BOM is used as is, and the others are read as Shift_JIS.
+func decodeCSV(reader io.Reader) (io.Reader, error) {
+	b, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(b, utf8BOM) {
+		return bytes.NewReader(b[len(utf8BOM):]), nil
+	}
+	if utf8.Valid(b) {
+		return bytes.NewReader(b), nil
+	}
+	return transform.NewReader(bytes.NewReader(b), japanese.ShiftJIS.NewDecoder()), nil
+}
+
+// csvRecordLines returns the line numbers of the records of the CSV text, which csv.Reader and gocsv don't tell.
+// The quoted fields can span over the lines, and the empty lines are skipped as csv.Reader does.
+func csvRecordLines(b []byte) []int {
+	lines := []int{}
+	line := 1
+	quoted := false
+	start := true
+	for _, c := range b {
+		if start && c != '\r' && c != '\n' {
+			lines = append(lines, line)
+			start = false
+		}
+		switch c {
+		case '"':
+			quoted = !quoted
+		case '\n':
+			line++
+			start = start || !quoted
+		}
+	}
+	return lines
+}
@@ -0,0 +1,99 @@
+package sync
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCSVRecordLines(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []int
+	}{
+		{"", []int{}},
+		{"a,b\n1,2\n3,4\n", []int{1, 2, 3}},
+		{"a,b\r\n1,2\r\n3,4", []int{1, 2, 3}},
+		{"a,b\n\n1,2\r\n\r\n3,4\n", []int{1, 3, 5}},
+		{"a,b\n1,\"two\nlines\"\n3,\"\"\"\n\"\"\"\n5,6\n", []int{1, 2, 4, 6}},
+		{"a\tb\n1\t\"x\n\ny\"\n2\tz\n", []int{1, 2, 5}},
+	}
+	for _, test := range tests {
+		if got := csvRecordLines([]byte(test.src)); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: want %v, got %v", test.src, test.want, got)
+		}
+	}
+}
+
+func TestConfigCSVEncoding(t *testing.T) {
+	config := &Config{Projects: []*Project{{ID: 1, Tickets: []*Ticket{fullTicket(5, "日本語の件名")}}}}
+	tests := []struct {
+		encoding string
+		prefix   []byte
+		subject  []byte
+	}{
+		{"", []byte("Project,"), []byte("日本語の件名")},
+		{EncodingUTF8, []byte("Project,"), []byte("日本語の件名")},
+		{EncodingUTF8BOM, []byte("\xEF\xBB\xBFProject,"), []byte("日本語の件名")},
+		{EncodingShiftJIS, []byte("Project,"), []byte("\x93\xfa\x96\x7b\x8c\xea\x82\xcc\x8c\x8f\x96\xbc")},
+	}
+	for _, test := range tests {
+		c := testConverter()
+		c.CSVEncoding = test.encoding
+		var b bytes.Buffer
+		if err := c.SaveConfigCSV(&b, config); err != nil {
+			t.Fatalf("%q: %s", test.encoding, err)
+		}
+		if !bytes.HasPrefix(b.Bytes(), test.prefix) || !bytes.Contains(b.Bytes(), test.subject) {
+			t.Errorf("%q: want the encoded subject, got %q", test.encoding, b.String())
+		}
+		// the encoding is detected on read
+		got, err := testConverter().readConfigCSV(&b)
+		if err != nil {
+			t.Fatalf("%q: %s", test.encoding, err)
+		}
+		assertConfig(t, test.encoding, config, got)
+	}
+
+	c := testConverter()
+	c.CSVEncoding = "latin1"
+	if err := c.SaveConfigCSV(&bytes.Buffer{}, config); err == nil {
+		t.Error("want the unsupported encoding error")
+	}
+}
+
+func TestConfigCSVDelimiter(t *testing.T) {
+	config := &Config{Projects: []*Project{{ID: 1, Tickets: []*Ticket{fullTicket(5, "a, b; c\td")}}}}
+	tests := []struct {
+		name      string
+		delimiter rune
+		tsv       bool
+		header    string
+	}{
+		{"comma", ',', false, "Project,"},
+		{"semicolon", ';', false, "Project;"},
+		{"tsv", ',', true, "Project\t"},
+		{"tsv with the delimiter", ';', true, "Project\t"},
+	}
+	for _, test := range tests {
+		c := testConverter()
+		c.CSVDelimiter = test.delimiter
+		save, read := c.SaveConfigCSV, c.readConfigCSV
+		if test.tsv {
+			save, read = c.SaveConfigTSV, c.readConfigTSV
+		}
+		var b bytes.Buffer
+		if err := save(&b, config); err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		if !strings.HasPrefix(b.String(), test.header) {
+			t.Errorf("%s: want the header starting with %q, got %q", test.name, test.header, b.String())
+		}
+		got, err := read(&b)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		assertConfig(t, test.name, config, got)
+	}
+}
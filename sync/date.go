@@ -0,0 +1,251 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Date expressions.
+// The start and due dates of the tickets can be written relatively, and are resolved to YYYY-MM-DD on import:
+//
+//	+5d, -1w, +2m, +1y, +3 workdays     from today
+//	today, tomorrow, yesterday
+//	next friday, this monday, friday    weekdays after today, or in this week
+//	end of month, start of next week    also for year; the end of a week is Friday
+//	parent.start, parent.due            dates of the parent ticket
+//	start, due                          dates of the ticket itself
+//	parent.start + 3 workdays           any of the above with the offsets
+const dateLayout = "2006-01-02"
+
+var (
+	datePattern           = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	dateExpressionPattern = regexp.MustCompile(`^(.*?)\s*((?:[+-]\s*\d+\s*[a-z]+\s*)*)$`)
+	dateOffsetPattern     = regexp.MustCompile(`([+-])\s*(\d+)\s*([a-z]+)`)
+	datePeriodPattern     = regexp.MustCompile(`^(start|end) of (?:(this|next|last) )?(week|month|year)$`)
+	dateWeekdayPattern    = regexp.MustCompile(`^(?:(this|next) )?(sunday|monday|tuesday|wednesday|thursday|friday|saturday)$`)
+
+	// errUnresolvedDate is the error of the reference to the expression not resolved yet.
+	errUnresolvedDate = errors.New("unresolved date")
+)
+
+// ResolveDates resolves the date expressions of the tickets, and validates the dates.
+// The expressions referring to the other dates are resolved after them regardless of the order of the fields,
+// and the circular references are errors.
+// changed is true if any expression is replaced with the date.
+func (c *Converter) ResolveDates(config *Config, today time.Time) (changed bool, err error) {
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	type dateField struct {
+		ticket *Ticket
+		parent *Ticket
+		name   string
+		date   **string
+	}
+	fields := []dateField{}
+	var collect func(parent *Ticket, t *Ticket)
+	collect = func(parent *Ticket, t *Ticket) {
+		for _, field := range []dateField{{t, parent, "start_date", &t.StartDate}, {t, parent, "due_date", &t.DueDate}} {
+			if *field.date != nil && **field.date != "" {
+				fields = append(fields, field)
+			}
+		}
+		for _, child := range t.Children {
+			collect(t, child)
+		}
+	}
+	for _, p := range config.Projects {
+		for _, t := range p.Tickets {
+			collect(nil, t)
+		}
+	}
+
+	fieldError := func(field dateField, expr string, err error) error {
+		subject := ""
+		if field.ticket.Subject != nil {
+			subject = *field.ticket.Subject
+		}
+		return fmt.Errorf("ticket #%d %s: invalid %s %q: %s", field.ticket.ID, subject, field.name, expr, err)
+	}
+	// each pass resolves the expressions referring to the dates resolved by the previous passes
+	for len(fields) > 0 {
+		pending := []dateField{}
+		for _, field := range fields {
+			t, parent := field.ticket, field.parent
+			ref := func(name string) (time.Time, error) {
+				target := t
+				if strings.HasPrefix(name, "parent.") {
+					if parent == nil {
+						return time.Time{}, fmt.Errorf("no parent to refer: %s", name)
+					}
+					target = parent
+					name = strings.TrimPrefix(name, "parent.")
+				}
+				var date *string
+				switch name {
+				case "start", "start_date":
+					date = target.StartDate
+				case "due", "due_date":
+					date = target.DueDate
+				default:
+					return time.Time{}, fmt.Errorf("unknown date: %s", name)
+				}
+				if date == nil || *date == "" {
+					return time.Time{}, fmt.Errorf("no date to refer: %s", name)
+				}
+				if !datePattern.MatchString(*date) {
+					return time.Time{}, errUnresolvedDate
+				}
+				return time.Parse(dateLayout, *date)
+			}
+			expr := **field.date
+			resolved, err := c.resolveDate(expr, today, ref)
+			if err == errUnresolvedDate {
+				pending = append(pending, field)
+				continue
+			}
+			if err != nil {
+				return false, fieldError(field, expr, err)
+			}
+			if resolved != expr {
+				*field.date = &resolved
+				changed = true
+			}
+		}
+		if len(pending) == len(fields) {
+			// the remaining expressions refer to each other
+			return false, fieldError(pending[0], **pending[0].date, errors.New("circular reference of the dates"))
+		}
+		fields = pending
+	}
+	return changed, nil
+}
+
+// resolveDate resolves the date expression to YYYY-MM-DD.
+func (c *Converter) resolveDate(expr string, today time.Time, ref func(name string) (time.Time, error)) (string, error) {
+	if datePattern.MatchString(expr) {
+		if _, err := time.Parse(dateLayout, expr); err != nil {
+			return "", err
+		}
+		return expr, nil
+	}
+	m := dateExpressionPattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(expr)))
+	if m == nil {
+		return "", errors.New("unsupported date expression")
+	}
+	date, err := c.dateBase(m[1], today, ref)
+	if err != nil {
+		return "", err
+	}
+	for _, offset := range dateOffsetPattern.FindAllStringSubmatch(m[2], -1) {
+		n, _ := strconv.Atoi(offset[2])
+		if offset[1] == "-" {
+			n = -n
+		}
+		switch offset[3] {
+		case "d", "day", "days":
+			date = date.AddDate(0, 0, n)
+		case "w", "week", "weeks":
+			date = date.AddDate(0, 0, 7*n)
+		case "m", "month", "months":
+			date = date.AddDate(0, n, 0)
+		case "y", "year", "years":
+			date = date.AddDate(n, 0, 0)
+		case "wd", "workday", "workdays":
+			date = c.addWorkdays(date, n)
+		default:
+			return "", fmt.Errorf("unknown unit: %s", offset[3])
+		}
+	}
+	return date.Format(dateLayout), nil
+}
+
+func (c *Converter) dateBase(base string, today time.Time, ref func(name string) (time.Time, error)) (time.Time, error) {
+	switch base {
+	case "", "today":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	case "start", "start_date", "due", "due_date", "parent.start", "parent.start_date", "parent.due", "parent.due_date":
+		return ref(base)
+	}
+	if datePattern.MatchString(base) {
+		return time.Parse(dateLayout, base)
+	}
+	if m := dateWeekdayPattern.FindStringSubmatch(base); m != nil {
+		var weekday time.Weekday
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			if strings.ToLower(d.String()) == m[2] {
+				weekday = d
+			}
+		}
+		if m[1] == "this" {
+			// the weekday of the week starting on Monday
+			return startOfWeek(today).AddDate(0, 0, (int(weekday)+6)%7), nil
+		}
+		days := (int(weekday) - int(today.Weekday()) + 7) % 7
+		if days == 0 {
+			days = 7
+		}
+		return today.AddDate(0, 0, days), nil
+	}
+	if m := datePeriodPattern.FindStringSubmatch(base); m != nil {
+		n := 0
+		switch m[2] {
+		case "next":
+			n = 1
+		case "last":
+			n = -1
+		}
+		switch m[3] {
+		case "week":
+			start := startOfWeek(today).AddDate(0, 0, 7*n)
+			if m[1] == "start" {
+				return start, nil
+			}
+			return start.AddDate(0, 0, 4), nil
+		case "month":
+			start := time.Date(today.Year(), today.Month()+time.Month(n), 1, 0, 0, 0, 0, time.UTC)
+			if m[1] == "start" {
+				return start, nil
+			}
+			return start.AddDate(0, 1, -1), nil
+		case "year":
+			start := time.Date(today.Year()+n, time.January, 1, 0, 0, 0, 0, time.UTC)
+			if m[1] == "start" {
+				return start, nil
+			}
+			return start.AddDate(1, 0, -1), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unsupported date: %s", base)
+}
+
+// startOfWeek returns Monday of the week of the date.
+func startOfWeek(date time.Time) time.Time {
+	return date.AddDate(0, 0, -((int(date.Weekday()) + 6) % 7))
+}
+
+// isWorkday returns whether the date is a working day, which is neither a weekend nor a holiday.
+func (c *Converter) isWorkday(date time.Time) bool {
+	return date.Weekday() != time.Saturday && date.Weekday() != time.Sunday && !c.Holidays[date.Format(dateLayout)]
+}
+
+// addWorkdays moves the date by the working days.
+func (c *Converter) addWorkdays(date time.Time, n int) time.Time {
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	for n > 0 {
+		date = date.AddDate(0, 0, step)
+		if c.isWorkday(date) {
+			n--
+		}
+	}
+	return date
+}
@@ -0,0 +1,157 @@
+package sync
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolveDate(t *testing.T) {
+	c := testConverter()
+	c.Holidays = map[string]bool{"2024-06-20": true}
+	// Wednesday
+	today := time.Date(2024, 6, 12, 0, 0, 0, 0, time.UTC)
+	ref := func(name string) (time.Time, error) {
+		if name == "start" {
+			return time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), nil
+		}
+		return time.Time{}, errors.New("no date to refer")
+	}
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"2024-06-30", "2024-06-30"},
+		{"today", "2024-06-12"},
+		{"tomorrow", "2024-06-13"},
+		{"yesterday", "2024-06-11"},
+		{"+5d", "2024-06-17"},
+		{"-1w", "2024-06-05"},
+		{"+2m", "2024-08-12"},
+		{"+1y", "2025-06-12"},
+		{"+3 workdays", "2024-06-17"},
+		{"+6wd", "2024-06-21"},
+		{"-3 workdays", "2024-06-07"},
+		{"friday", "2024-06-14"},
+		{"next friday", "2024-06-14"},
+		{"wednesday", "2024-06-19"},
+		{"this monday", "2024-06-10"},
+		{"this sunday", "2024-06-16"},
+		{"start of week", "2024-06-10"},
+		{"end of week", "2024-06-14"},
+		{"start of next week", "2024-06-17"},
+		{"end of month", "2024-06-30"},
+		{"end of last month", "2024-05-31"},
+		{"start of year", "2024-01-01"},
+		{"end of next year", "2025-12-31"},
+		{" Next Friday + 1d ", "2024-06-15"},
+		{"2024-06-30 - 1w + 2d", "2024-06-25"},
+		{"start + 2d", "2024-06-03"},
+	}
+	for _, test := range tests {
+		got, err := c.resolveDate(test.expr, today, ref)
+		if err != nil {
+			t.Errorf("%q: %s", test.expr, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%q: want %s, got %s", test.expr, test.want, got)
+		}
+	}
+
+	for _, expr := range []string{"2024-02-30", "someday", "+1q", "due", "next month"} {
+		if got, err := c.resolveDate(expr, today, ref); err == nil {
+			t.Errorf("%q: want an error, got %s", expr, got)
+		}
+	}
+}
+
+func TestResolveDates(t *testing.T) {
+	c := testConverter()
+	today := time.Date(2024, 6, 12, 15, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		ticket  *Ticket
+		want    *Ticket
+		changed bool
+	}{
+		{
+			"dates",
+			&Ticket{ID: 1, StartDate: stringPtr("2024-06-10"), DueDate: stringPtr("2024-06-14")},
+			&Ticket{ID: 1, StartDate: stringPtr("2024-06-10"), DueDate: stringPtr("2024-06-14")},
+			false,
+		},
+		{
+			"expressions",
+			&Ticket{ID: 1, StartDate: stringPtr("tomorrow"), DueDate: stringPtr("start + 3 workdays")},
+			&Ticket{ID: 1, StartDate: stringPtr("2024-06-13"), DueDate: stringPtr("2024-06-18")},
+			true,
+		},
+		{
+			"parent dates",
+			&Ticket{ID: 1, StartDate: stringPtr("2024-06-10"), DueDate: stringPtr("+1w"), Children: []*Ticket{
+				{ID: 2, StartDate: stringPtr("parent.start + 1d"), DueDate: stringPtr("parent.due_date")},
+			}},
+			&Ticket{ID: 1, StartDate: stringPtr("2024-06-10"), DueDate: stringPtr("2024-06-19"), Children: []*Ticket{
+				{ID: 2, StartDate: stringPtr("2024-06-11"), DueDate: stringPtr("2024-06-19")},
+			}},
+			true,
+		},
+		{
+			"reference to the expression after it",
+			&Ticket{ID: 1, StartDate: stringPtr("due - 3d"), DueDate: stringPtr("+1w")},
+			&Ticket{ID: 1, StartDate: stringPtr("2024-06-16"), DueDate: stringPtr("2024-06-19")},
+			true,
+		},
+		{
+			"parent dates referring to the own dates",
+			&Ticket{ID: 1, StartDate: stringPtr("due - 1w"), DueDate: stringPtr("2024-06-28"), Children: []*Ticket{
+				{ID: 2, StartDate: stringPtr("parent.start"), DueDate: stringPtr("start + 2d")},
+			}},
+			&Ticket{ID: 1, StartDate: stringPtr("2024-06-21"), DueDate: stringPtr("2024-06-28"), Children: []*Ticket{
+				{ID: 2, StartDate: stringPtr("2024-06-21"), DueDate: stringPtr("2024-06-23")},
+			}},
+			true,
+		},
+		{
+			"no dates",
+			&Ticket{ID: 1, StartDate: stringPtr(""), Children: []*Ticket{{ID: 2}}},
+			&Ticket{ID: 1, StartDate: stringPtr(""), Children: []*Ticket{{ID: 2}}},
+			false,
+		},
+	}
+	for _, test := range tests {
+		config := &Config{Projects: []*Project{{ID: 1, Tickets: []*Ticket{test.ticket}}}}
+		changed, err := c.ResolveDates(config, today)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		if changed != test.changed {
+			t.Errorf("%s: changed: want %v, got %v", test.name, test.changed, changed)
+		}
+		assertConfig(t, test.name, &Config{Projects: []*Project{{ID: 1, Tickets: []*Ticket{test.want}}}}, config)
+	}
+
+	errorTests := []struct {
+		name   string
+		ticket *Ticket
+		err    string
+	}{
+		{"invalid date", &Ticket{ID: 1, DueDate: stringPtr("2024-13-01")}, "due_date"},
+		{"no parent", &Ticket{ID: 1, DueDate: stringPtr("parent.due")}, "no parent to refer"},
+		{"no date to refer", &Ticket{ID: 1, DueDate: stringPtr("start + 1d")}, "no date to refer"},
+		{"cycle", &Ticket{ID: 1, StartDate: stringPtr("due"), DueDate: stringPtr("start")}, "circular reference"},
+		{"reference to the cycle", &Ticket{ID: 1, StartDate: stringPtr("due"), DueDate: stringPtr("start"), Children: []*Ticket{
+			{ID: 2, StartDate: stringPtr("parent.start")},
+		}}, `ticket #1 : invalid start_date "due": circular reference`},
+		{"reference to the invalid expression", &Ticket{ID: 1, StartDate: stringPtr("due"), DueDate: stringPtr("someday")}, `invalid due_date "someday"`},
+		{"child", &Ticket{ID: 1, Children: []*Ticket{{ID: 2, StartDate: stringPtr("someday")}}}, "ticket #2"},
+	}
+	for _, test := range errorTests {
+		config := &Config{Projects: []*Project{{ID: 1, Tickets: []*Ticket{test.ticket}}}}
+		if _, err := c.ResolveDates(config, today); err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: want the error of %q, got %v", test.name, test.err, err)
+		}
+	}
+}
@@ -1,5 +1,9 @@
 package sync
 
+import (
+	"strconv"
+)
+
 type (
 	Change      string
 	IssueChange struct {
@@ -16,32 +20,38 @@ const (
 )
 
 func DiffTickets(converter *Converter, config1 *Config, config2 *Config) ([]IssueChange, error) {
-	ticketMap := func(config *Config) (map[int]*Ticket, error) {
+	ticketMap := func(config *Config) (map[int]*Ticket, []*Ticket, error) {
 		if config == nil || config.Projects == nil {
-			return map[int]*Ticket{}, nil
+			return map[int]*Ticket{}, nil, nil
 		}
 		tickets, err := converter.toFlat(config)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		m := map[int]*Ticket{}
 		for _, t := range tickets {
-			m[t.ID] = t
+			// the new tickets don't have IDs yet
+			if t.ID != 0 {
+				m[t.ID] = t
+			}
 		}
-		return m, nil
+		return m, tickets, nil
 	}
-	tickets1, err := ticketMap(config1)
+	tickets1, list1, err := ticketMap(config1)
 	if err != nil {
 		return nil, err
 	}
-	tickets2, err := ticketMap(config2)
+	tickets2, list2, err := ticketMap(config2)
 	if err != nil {
 		return nil, err
 	}
 
 	changes := []IssueChange{}
-	for _, t1 := range tickets1 {
+	for _, t1 := range list1 {
+		if t1.ID == 0 {
+			continue
+		}
 		t2, ok := tickets2[t1.ID]
 		if ok {
 			if !equals(t1, t2) {
@@ -51,9 +61,8 @@ func DiffTickets(converter *Converter, config1 *Config, config2 *Config) ([]Issu
 			changes = append(changes, IssueChange{t1, t2, ChangeRemoved})
 		}
 	}
-	for _, t2 := range tickets2 {
-		_, ok := tickets1[t2.ID]
-		if !ok {
+	for _, t2 := range list2 {
+		if _, ok := tickets1[t2.ID]; !ok || t2.ID == 0 {
 			changes = append(changes, IssueChange{nil, t2, ChangeAdded})
 		}
 	}
@@ -61,53 +70,63 @@ func DiffTickets(converter *Converter, config1 *Config, config2 *Config) ([]Issu
 }
 
 func equals(t1 *Ticket, t2 *Ticket) bool {
-	if !equalsString(t1.Subject, t2.Subject) {
-		return false
-	}
-	if !equalsString(t1.Description, t2.Description) {
-		return false
-	}
-	if t1.ParentID != t2.ParentID {
-		return false
-	}
-	if !equalsString(t1.Priority, t2.Priority) {
-		return false
-	}
-	if !equalsString(t1.Project, t2.Project) {
-		return false
-	}
-	if !equalsString(t1.DueDate, t2.DueDate) {
-		return false
-	}
-	if !equalsString(t1.StartDate, t2.StartDate) {
-		return false
-	}
-	if !equalsString(t1.Status, t2.Status) {
-		return false
-	}
-	if !equalsString(t1.Tracker, t2.Tracker) {
-		return false
-	}
-	if !equalsString(t1.Assignee, t2.Assignee) {
-		return false
-	}
-	if !equalsInt(t1.DoneRatio, t2.DoneRatio) {
-		return false
-	}
-	return true
+	return len(DiffFields(t1, t2)) == 0
 }
 
-func equalsString(s1, s2 *string) bool {
-	if s1 == nil && s2 == nil {
-		return true
+// FieldChange is a changed field of the ticket.
+// The values are nil for the unset fields.
+type FieldChange struct {
+	Field string
+	Old   *string
+	New   *string
+}
+
+// DiffFields returns the changed fields synced with Redmine from t1 to t2.
+func DiffFields(t1 *Ticket, t2 *Ticket) []FieldChange {
+	fields1, fields2 := ticketFields(t1), ticketFields(t2)
+	changes := []FieldChange{}
+	for i, f := range fields1 {
+		if !equalsString(f.value, fields2[i].value) {
+			changes = append(changes, FieldChange{f.name, f.value, fields2[i].value})
+		}
 	}
-	if s1 != nil && s2 != nil {
-		return *s1 == *s2
+	return changes
+}
+
+type ticketField struct {
+	name  string
+	value *string
+}
+
+// ticketFields returns the fields synced with Redmine as strings.
+func ticketFields(t *Ticket) []ticketField {
+	itoa := func(i *int) *string {
+		if i == nil {
+			return nil
+		}
+		s := strconv.Itoa(*i)
+		return &s
+	}
+	var parent *int
+	if t.ParentID != 0 {
+		parent = &t.ParentID
+	}
+	return []ticketField{
+		{"subject", t.Subject},
+		{"project", t.Project},
+		{"parent", itoa(parent)},
+		{"tracker", t.Tracker},
+		{"status", t.Status},
+		{"priority", t.Priority},
+		{"assignee", t.Assignee},
+		{"start_date", t.StartDate},
+		{"due_date", t.DueDate},
+		{"done_ratio", itoa(t.DoneRatio)},
+		{"description", t.Description},
 	}
-	return false
 }
 
-func equalsInt(s1, s2 *int) bool {
+func equalsString(s1, s2 *string) bool {
 	if s1 == nil && s2 == nil {
 		return true
 	}
@@ -0,0 +1,190 @@
+package sync
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	redmine "github.com/uphy/go-redmine"
+)
+
+// DiffServer compares the issues on the server with the tickets of the config.
+//
+// The changes have the tickets of the server as Ticket1 and the tickets of the config as Ticket2.
+// ChangeAdded is the new ticket or the ticket not on the server, and ChangeRemoved is the issue of the projects
+// of the config which is not in the config.
+// The issues of the projects are filtered with the options except the project.
+func (s *Sync) DiffServer(config *Config, options IssueFilterOptions) ([]IssueChange, error) {
+	if _, err := s.Converter.ResolveDates(config, time.Now()); err != nil {
+		return nil, err
+	}
+	tickets, err := s.Converter.toFlat(config)
+	if err != nil {
+		return nil, err
+	}
+
+	server := map[int]*Ticket{}
+	addIssue := func(issue redmine.Issue) error {
+		t := &Ticket{}
+		if err := s.Converter.mergeIssueToTicket(issue, t); err != nil {
+			return err
+		}
+		server[t.ID] = t
+		return nil
+	}
+	for _, p := range config.Projects {
+		name, err := s.Converter.Projects.FindNameByID(p.ID)
+		if err != nil {
+			return nil, err
+		}
+		options.Project = name
+		filter, err := s.Converter.IssueFilter(&options)
+		if err != nil {
+			return nil, err
+		}
+		issues, err := s.client.IssuesByFilter(filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, issue := range issues {
+			if err := addIssue(issue); err != nil {
+				return nil, err
+			}
+		}
+	}
+	// the tickets out of the filter, such as the closed or moved ones
+	local := map[int]bool{}
+	missing := []int{}
+	for _, t := range tickets {
+		if t.ID == 0 {
+			continue
+		}
+		local[t.ID] = true
+		if _, ok := server[t.ID]; !ok {
+			missing = append(missing, t.ID)
+		}
+	}
+	// the deleted issues are not in the response
+	issues, err := s.Converter.issues(missing)
+	if err != nil {
+		return nil, err
+	}
+	for _, issue := range issues {
+		if err := addIssue(issue); err != nil {
+			return nil, err
+		}
+	}
+
+	changes := []IssueChange{}
+	for _, t := range tickets {
+		st, ok := server[t.ID]
+		if t.ID == 0 || !ok {
+			changes = append(changes, IssueChange{nil, t, ChangeAdded})
+			continue
+		}
+		if len(syncedFieldChanges(st, t)) > 0 {
+			changes = append(changes, IssueChange{st, t, ChangeUpdated})
+		}
+	}
+	removed := []IssueChange{}
+	for id, st := range server {
+		if !local[id] {
+			removed = append(removed, IssueChange{st, nil, ChangeRemoved})
+		}
+	}
+	sort.Slice(removed, func(i, j int) bool {
+		return removed[i].Ticket1.ID < removed[j].Ticket1.ID
+	})
+	return append(changes, removed...), nil
+}
+
+// syncedFieldChanges returns the changed fields which are updated by importing t2.
+// The unset fields of t2 are not updated except the parent, and the empty assignee is the unassigned.
+func syncedFieldChanges(t1 *Ticket, t2 *Ticket) []FieldChange {
+	changes := []FieldChange{}
+	for _, change := range DiffFields(t1, t2) {
+		if change.New == nil && change.Field != "parent" {
+			continue
+		}
+		if change.Field == "assignee" && (change.Old == nil || *change.Old == "") && *change.New == "" {
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+const (
+	colorReset = "\x1b[0m"
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+	colorCyan  = "\x1b[36m"
+)
+
+// WriteDiff writes the changes from the old config to the new config as a unified diff per field.
+func WriteDiff(w io.Writer, changes []IssueChange, oldName string, newName string, color bool) {
+	paint := func(c string, line string) string {
+		if !color {
+			return line
+		}
+		return c + line + colorReset
+	}
+	writeField := func(c string, sign string, name string, value *string) {
+		if value == nil {
+			return
+		}
+		for i, line := range strings.Split(*value, "\n") {
+			switch {
+			case i > 0:
+				line = "  " + line
+			case line == "":
+				line = name + ":"
+			default:
+				line = name + ": " + line
+			}
+			fmt.Fprintln(w, paint(c, sign+line))
+		}
+	}
+	header := func(t *Ticket, note string) {
+		subject := ""
+		if t.Subject != nil {
+			subject = " " + *t.Subject
+		}
+		if note != "" {
+			note = " (" + note + ")"
+		}
+		fmt.Fprintln(w, paint(colorCyan, fmt.Sprintf("@@ #%d%s%s @@", t.ID, subject, note)))
+	}
+
+	if len(changes) == 0 {
+		return
+	}
+	fmt.Fprintln(w, paint(colorRed, "--- "+oldName))
+	fmt.Fprintln(w, paint(colorGreen, "+++ "+newName))
+	for _, change := range changes {
+		switch change.Change {
+		case ChangeUpdated:
+			header(change.Ticket2, "")
+			for _, f := range syncedFieldChanges(change.Ticket1, change.Ticket2) {
+				writeField(colorRed, "-", f.Field, f.Old)
+				writeField(colorGreen, "+", f.Field, f.New)
+			}
+		case ChangeAdded:
+			note := "not in " + oldName
+			if change.Ticket2.ID == 0 {
+				note = "new"
+			}
+			header(change.Ticket2, note)
+			for _, f := range ticketFields(change.Ticket2) {
+				writeField(colorGreen, "+", f.name, f.value)
+			}
+		case ChangeRemoved:
+			header(change.Ticket1, "not in "+newName)
+			for _, f := range ticketFields(change.Ticket1) {
+				writeField(colorRed, "-", f.name, f.value)
+			}
+		}
+	}
+}
@@ -0,0 +1,510 @@
+package sync
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Directory format.
+// Each ticket is a markdown file named like "123-subject-slug.md" in the folder of its project.
+// The YAML front matter holds the ticket fields and the body is the description.
+//
+//	---
+//	id: 124
+//	parent: 123
+//	subject: doc1
+//	status: New
+//	---
+//	description
+//
+// The parent is given by the parent key, or by the folder named after the parent ticket file such as
+// "123-parent-ticket/new-child.md".
+
+type frontMatter struct {
+	ID        int     `yaml:"id,omitempty"`
+	Project   string  `yaml:"project,omitempty"`
+	Parent    int     `yaml:"parent,omitempty"`
+	Subject   *string `yaml:"subject,omitempty"`
+	Assignee  *string `yaml:"assignee,omitempty"`
+	Status    *string `yaml:"status,omitempty"`
+	DoneRatio *int    `yaml:"done_ratio,omitempty"`
+	Tracker   *string `yaml:"tracker,omitempty"`
+	StartDate *string `yaml:"start_date,omitempty"`
+	DueDate   *string `yaml:"due_date,omitempty"`
+	Priority  *string `yaml:"priority,omitempty"`
+	Duration  *int    `yaml:"duration,omitempty"`
+}
+
+var ticketFilePattern = regexp.MustCompile(`^(\d+)(?:-|$)`)
+
+// dirFormat is the directory of the ticket files, which is read and written by the name of the directory.
+// The readers and the writers must be the files of the directories.
+type dirFormat struct{}
+
+func (dirFormat) Name() string {
+	return "dir"
+}
+
+func (dirFormat) Extensions() []string {
+	return nil
+}
+
+func (dirFormat) Read(c *Converter, reader io.Reader) (*Config, error) {
+	f, ok := reader.(*os.File)
+	if !ok || !isDir(f.Name()) {
+		return nil, errors.New("dir format requires a directory")
+	}
+	return c.readConfigDir(f.Name())
+}
+
+func (dirFormat) Write(c *Converter, writer io.Writer, config *Config) error {
+	f, ok := writer.(*os.File)
+	if !ok || !isDir(f.Name()) {
+		return errors.New("dir format requires a directory")
+	}
+	return c.SaveConfigDir(f.Name(), config)
+}
+
+func (dirFormat) writePath(c *Converter, name string, config *Config) error {
+	return c.SaveConfigDir(name, config)
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func (c *Converter) readConfigDir(dir string) (*Config, error) {
+	type entry struct {
+		ticket   *Ticket
+		project  string
+		parentID int
+	}
+	entries := []*entry{}
+	fileToTickets := map[string]*Ticket{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != dir && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.ToLower(filepath.Ext(path)) != ".md" {
+			return nil
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		fm, body, ok := splitFrontMatter(b)
+		if !ok {
+			// not a ticket file, such as README.md
+			return nil
+		}
+		var m frontMatter
+		if err := yaml.Unmarshal(fm, &m); err != nil {
+			return fmt.Errorf("%s: %s", path, err)
+		}
+		t := &Ticket{
+			ID:        m.ID,
+			Subject:   m.Subject,
+			Assignee:  m.Assignee,
+			Status:    m.Status,
+			DoneRatio: m.DoneRatio,
+			Tracker:   m.Tracker,
+			StartDate: m.StartDate,
+			DueDate:   m.DueDate,
+			Priority:  m.Priority,
+			Duration:  m.Duration,
+			path:      path,
+		}
+		if t.ID == 0 {
+			// the ID in the file name is used if the front matter lacks it
+			if match := ticketFilePattern.FindStringSubmatch(info.Name()); match != nil {
+				t.ID, _ = strconv.Atoi(match[1])
+			}
+		}
+		description := strings.TrimRight(body, "\n")
+		t.Description = &description
+
+		e := &entry{ticket: t, project: m.Project, parentID: m.Parent}
+		if e.project == "" {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			parts := strings.Split(filepath.ToSlash(rel), "/")
+			if len(parts) < 2 {
+				return fmt.Errorf("%s: project is unknown; put the file in the project folder or add the project key", path)
+			}
+			e.project = parts[0]
+		}
+		entries = append(entries, e)
+		fileToTickets[strings.TrimSuffix(path, filepath.Ext(path))] = t
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	idToTickets := map[int]*Ticket{}
+	for _, e := range entries {
+		if e.ticket.ID != 0 {
+			if t, ok := idToTickets[e.ticket.ID]; ok {
+				return nil, fmt.Errorf("%s: duplicate ID %d: also in %s", e.ticket.path, e.ticket.ID, t.path)
+			}
+			idToTickets[e.ticket.ID] = e.ticket
+		}
+	}
+	parentOf := map[*Ticket]*Ticket{}
+	for _, e := range entries {
+		if e.parentID != 0 {
+			p, ok := idToTickets[e.parentID]
+			if !ok {
+				return nil, fmt.Errorf("%s: no such parent: %d", e.ticket.path, e.parentID)
+			}
+			parentOf[e.ticket] = p
+		} else if p := fileToTickets[filepath.Dir(e.ticket.path)]; p != nil {
+			parentOf[e.ticket] = p
+		}
+	}
+	// the tickets without the IDs have the negative keys, which can be in a cycle through the folders
+	keys := map[*Ticket]int{}
+	keyToTickets := map[int]*Ticket{}
+	for i, e := range entries {
+		key := e.ticket.ID
+		if key == 0 {
+			key = -i - 1
+		}
+		keys[e.ticket] = key
+		keyToTickets[key] = e.ticket
+	}
+	parents := map[int]int{}
+	for t, p := range parentOf {
+		parents[keys[t]] = keys[p]
+	}
+	name := func(key int) string {
+		if key < 0 {
+			return keyToTickets[key].path
+		}
+		return "#" + strconv.Itoa(key)
+	}
+	cycles := parentCycles(parents)
+	if len(cycles) > 0 && c.parentCycle == nil {
+		return nil, fmt.Errorf("%s: parent cycle: %s", dir, formatParentCycles(cycles, name))
+	}
+	for _, cycle := range cycles {
+		t := keyToTickets[cycle[0]]
+		c.parentCycle(t, formatParentCycles([][]int{cycle}, name))
+		delete(parentOf, t)
+	}
+	config := &Config{}
+	for _, e := range entries {
+		if parent := parentOf[e.ticket]; parent != nil {
+			parent.Children = append(parent.Children, e.ticket)
+			continue
+		}
+		projectID, err := c.projectOfFolder(e.project)
+		if err != nil {
+			return nil, err
+		}
+		project := config.findOrCreateProject(projectID)
+		project.Tickets = append(project.Tickets, e.ticket)
+	}
+	if _, err := c.toFlat(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// projectOfFolder returns the ID of the project of the folder name, which is the project name or its folder name.
+func (c *Converter) projectOfFolder(folder string) (int, error) {
+	id, err := c.Projects.FindIDByName(folder)
+	if err == nil {
+		return id, nil
+	}
+	names, e := c.Projects.list()
+	if e != nil {
+		return 0, e
+	}
+	for _, name := range names {
+		if projectFolderName(name) == folder {
+			return c.Projects.FindIDByName(name)
+		}
+	}
+	return 0, err
+}
+
+// SaveConfigDir writes the tickets to the directory.
+// The tickets read from the directory are written back to their files, which are renamed only when the IDs are
+// assigned to the new tickets.
+// The other tickets replace the existing files of the same IDs, which are renamed after the subjects.
+// A file name taken by another file, such as the one of a new ticket of the same subject, gets a number suffix.
+func (c *Converter) SaveConfigDir(dir string, config *Config) error {
+	tickets, err := c.toFlat(config)
+	if err != nil {
+		return err
+	}
+	existing, err := ticketFiles(dir)
+	if err != nil {
+		return err
+	}
+	// replaced are the tickets written to the existing files
+	replaced := map[*Ticket]bool{}
+	for _, t := range tickets {
+		if t.path != "" || t.ID == 0 || len(existing[t.ID]) == 0 {
+			continue
+		}
+		t.path = existing[t.ID][0]
+		replaced[t] = true
+		// the stale files of the ticket, such as the one of the old subject
+		for _, stale := range existing[t.ID][1:] {
+			if err := os.Remove(stale); err != nil {
+				return err
+			}
+		}
+	}
+	// renamed folders of the children, from the original path to the new one
+	renames := map[string]string{}
+	resolve := func(path string) string {
+		longest := ""
+		for old := range renames {
+			if (path == old || strings.HasPrefix(path, old+string(filepath.Separator))) && len(old) > len(longest) {
+				longest = old
+			}
+		}
+		if longest == "" {
+			return path
+		}
+		return renames[longest] + path[len(longest):]
+	}
+	stem := func(path string) string {
+		return strings.TrimSuffix(path, filepath.Ext(path))
+	}
+	// written are the files written in this save, which the other tickets of the same file names don't overwrite
+	written := map[string]bool{}
+	taken := func(path string) bool {
+		if written[path] {
+			return true
+		}
+		_, err := os.Stat(path)
+		return err == nil
+	}
+
+	var save func(t *Ticket) error
+	save = func(t *Ticket) error {
+		current := ""
+		if t.path != "" {
+			current = resolve(t.path)
+		}
+		path := current
+		if path == "" {
+			path = filepath.Join(dir, projectFolderName(*t.Project), ticketFileName(t))
+		} else if t.ID != 0 && (replaced[t] || !ticketFilePattern.MatchString(filepath.Base(path))) {
+			path = filepath.Join(filepath.Dir(path), ticketFileName(t))
+		}
+		if path != current {
+			path = uniquePath(path, taken)
+		}
+		written[path] = true
+
+		fm := frontMatter{
+			ID:        t.ID,
+			Parent:    t.ParentID,
+			Subject:   t.Subject,
+			Assignee:  t.Assignee,
+			Status:    t.Status,
+			DoneRatio: t.DoneRatio,
+			Tracker:   t.Tracker,
+			StartDate: t.StartDate,
+			DueDate:   t.DueDate,
+			Priority:  t.Priority,
+			Duration:  t.Duration,
+		}
+		if folder := projectFolderName(*t.Project); folder != *t.Project {
+			// the folder can't be the name of the project
+			fm.Project = *t.Project
+		}
+		b, err := yaml.Marshal(&fm)
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		buf.WriteString("---\n")
+		buf.Write(b)
+		buf.WriteString("---\n")
+		if t.Description != nil && *t.Description != "" {
+			buf.WriteString(strings.Replace(*t.Description, "\r\n", "\n", -1))
+			buf.WriteString("\n")
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			return err
+		}
+		if current != "" && current != path {
+			if err := os.Remove(current); err != nil {
+				return err
+			}
+			// move the folder of the children along with the file
+			if isDir(stem(current)) {
+				if err := os.Rename(stem(current), stem(path)); err != nil {
+					return err
+				}
+				renames[stem(t.path)] = stem(path)
+			}
+		}
+		t.path = path
+		for _, child := range t.Children {
+			if err := save(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, p := range config.Projects {
+		for _, t := range p.Tickets {
+			if err := save(t); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ticketFiles returns the ticket files in the directory by the IDs.
+func ticketFiles(dir string) (map[int][]string, error) {
+	files := map[int][]string{}
+	if !isDir(dir) {
+		return files, nil
+	}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != dir && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.ToLower(filepath.Ext(path)) != ".md" {
+			return nil
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		fm, _, ok := splitFrontMatter(b)
+		if !ok {
+			return nil
+		}
+		var m frontMatter
+		if err := yaml.Unmarshal(fm, &m); err != nil {
+			return fmt.Errorf("%s: %s", path, err)
+		}
+		if m.ID == 0 {
+			if match := ticketFilePattern.FindStringSubmatch(info.Name()); match != nil {
+				m.ID, _ = strconv.Atoi(match[1])
+			}
+		}
+		if m.ID != 0 {
+			files[m.ID] = append(files[m.ID], path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// projectFolderName returns the name of the project folder, replacing the characters which can't be in the file
+// names, and the leading dot of the hidden folders.
+func projectFolderName(project string) string {
+	name := strings.Map(func(r rune) rune {
+		if r < 0x20 || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, project)
+	if strings.HasPrefix(name, ".") {
+		name = "_" + name[1:]
+	}
+	if name == "" {
+		return "_"
+	}
+	return name
+}
+
+func ticketFileName(t *Ticket) string {
+	slug := ""
+	if t.Subject != nil {
+		slug = slugify(*t.Subject)
+	}
+	if t.ID == 0 {
+		if slug == "" {
+			slug = "new"
+		}
+		return slug + ".md"
+	}
+	if slug == "" {
+		return strconv.Itoa(t.ID) + ".md"
+	}
+	return strconv.Itoa(t.ID) + "-" + slug + ".md"
+}
+
+// uniquePath returns the path, or the path with the smallest number suffix such as "subject-2.md" if it is taken.
+func uniquePath(path string, taken func(path string) bool) string {
+	ext := filepath.Ext(path)
+	unique := path
+	for i := 2; taken(unique); i++ {
+		unique = strings.TrimSuffix(path, ext) + "-" + strconv.Itoa(i) + ext
+	}
+	return unique
+}
+
+// slugify converts the subject to the lower-cased words joined with hyphens.
+func slugify(s string) string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	slug := strings.Join(words, "-")
+	if r := []rune(slug); len(r) > 50 {
+		slug = strings.TrimRight(string(r[:50]), "-")
+	}
+	return slug
+}
+
+// splitFrontMatter splits the file into the YAML front matter and the body.
+func splitFrontMatter(b []byte) (frontMatter []byte, body string, ok bool) {
+	s := strings.Replace(string(b), "\r\n", "\n", -1)
+	if !strings.HasPrefix(s, "---\n") {
+		return nil, "", false
+	}
+	s = s[len("---\n"):]
+	if strings.HasPrefix(s, "---\n") {
+		return nil, s[len("---\n"):], true
+	}
+	end := strings.Index(s, "\n---\n")
+	if end < 0 {
+		if strings.HasSuffix(s, "\n---") {
+			return []byte(strings.TrimSuffix(s, "\n---")), "", true
+		}
+		return nil, "", false
+	}
+	return []byte(s[:end]), s[end+len("\n---\n"):], true
+}
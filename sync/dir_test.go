@@ -0,0 +1,215 @@
+package sync
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// listFiles returns the slash separated paths of the files in the directory.
+func listFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	files := []string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		files = append(files, filepath.ToSlash(rel))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func TestSaveConfigDirFiles(t *testing.T) {
+	c := testConverter()
+	tests := []struct {
+		name   string
+		config *Config
+		files  map[string]string
+	}{
+		{
+			"hierarchy",
+			&Config{Projects: []*Project{{ID: 1, Tickets: []*Ticket{
+				{ID: 5, Subject: stringPtr("Parent ticket"), Status: stringPtr("New"), Description: stringPtr("line1\n\nline3"), Children: []*Ticket{
+					{ID: 6, Subject: stringPtr("child"), Description: stringPtr("")},
+				}},
+			}}}},
+			map[string]string{
+				"aaaa/5-parent-ticket.md": "---\nid: 5\nsubject: Parent ticket\nstatus: New\n---\nline1\n\nline3\n",
+				"aaaa/6-child.md":         "---\nid: 6\nparent: 5\nsubject: child\n---\n",
+			},
+		},
+		{
+			"project name which can't be a folder",
+			&Config{Projects: []*Project{{ID: 2, Tickets: []*Ticket{
+				{ID: 7, Subject: stringPtr("x"), Description: stringPtr("")},
+			}}}},
+			map[string]string{
+				"a_b_ c/7-x.md": "---\nid: 7\nproject: 'a/b: c'\nsubject: x\n---\n",
+			},
+		},
+	}
+	for _, test := range tests {
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+		if err := c.SaveConfigDir(dir, test.config); err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		files := listFiles(t, dir)
+		if len(files) != len(test.files) {
+			t.Errorf("%s: want files %v, got %v", test.name, test.files, files)
+		}
+		for _, name := range files {
+			b, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want, ok := test.files[name]; !ok || string(b) != want {
+				t.Errorf("%s: %s: want\n%s\ngot\n%s", test.name, name, want, b)
+			}
+		}
+		got, err := c.readConfigDir(dir)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		assertConfig(t, test.name, test.config, got)
+	}
+}
+
+func TestReadConfigDirParent(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"aaaa/5-parent.md":                "---\nid: 5\nsubject: parent\n---\n",
+		"aaaa/5-parent/by-folder.md":      "---\nsubject: by folder\n---\n",
+		"aaaa/by-key.md":                  "---\nparent: 5\nsubject: by key\n---\n",
+		"aaaa/other/6-key-over-folder.md": "---\nparent: 5\nsubject: key over folder\n---\n",
+		"aaaa/other.md":                   "---\nsubject: other\n---\n",
+		"moved.md":                        "---\nproject: aaaa\nparent: 5\nsubject: moved\n---\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := testConverter().readConfigDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Config{Projects: []*Project{{ID: 1, Tickets: []*Ticket{
+		{ID: 5, Subject: stringPtr("parent"), Description: stringPtr(""), Children: []*Ticket{
+			{Subject: stringPtr("by folder"), Description: stringPtr("")},
+			{Subject: stringPtr("by key"), Description: stringPtr("")},
+			{ID: 6, Subject: stringPtr("key over folder"), Description: stringPtr("")},
+			{Subject: stringPtr("moved"), Description: stringPtr("")},
+		}},
+		{Subject: stringPtr("other"), Description: stringPtr("")},
+	}}}}
+	assertConfig(t, "parent", want, got)
+}
+
+func TestSaveConfigDirReplacesExistingFiles(t *testing.T) {
+	c := testConverter()
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	write := func(name string, content string) {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("aaaa/5-old-subject.md", "---\nid: 5\nsubject: old subject\n---\n")
+	write("aaaa/5-old-subject/6-child.md", "---\nid: 6\nsubject: child\n---\n")
+	// the stale file of the same ID
+	write("aaaa/5-older-subject.md", "---\nsubject: older subject\n---\n")
+	write("aaaa/README.md", "not a ticket\n")
+
+	config := &Config{Projects: []*Project{{ID: 1, Tickets: []*Ticket{
+		{ID: 5, Subject: stringPtr("renamed subject"), Description: stringPtr(""), Children: []*Ticket{
+			{ID: 6, Subject: stringPtr("child"), Description: stringPtr("")},
+		}},
+	}}}}
+	if err := c.SaveConfigDir(dir, config); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"aaaa/5-renamed-subject.md", "aaaa/5-renamed-subject/6-child.md", "aaaa/README.md"}
+	if files := listFiles(t, dir); strings.Join(files, ",") != strings.Join(want, ",") {
+		t.Errorf("want files %v, got %v", want, files)
+	}
+	got, err := c.readConfigDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertConfig(t, "replaced", config, got)
+}
+
+func TestSaveConfigDirSameFileName(t *testing.T) {
+	c := testConverter()
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(filepath.Join(dir, "aaaa"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "aaaa", "readme.md"), []byte("not a ticket\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config := &Config{Projects: []*Project{{ID: 1, Tickets: []*Ticket{
+		{Subject: stringPtr("Same subject"), Description: stringPtr("first")},
+		{Subject: stringPtr("same subject!"), Description: stringPtr("second")},
+		{Subject: stringPtr("README"), Description: stringPtr("third")},
+	}}}}
+	if err := c.SaveConfigDir(dir, config); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"aaaa/readme-2.md", "aaaa/readme.md", "aaaa/same-subject-2.md", "aaaa/same-subject.md"}
+	if files := listFiles(t, dir); strings.Join(files, ",") != strings.Join(want, ",") {
+		t.Errorf("want files %v, got %v", want, files)
+	}
+	// saved again to the same files
+	if err := c.SaveConfigDir(dir, config); err != nil {
+		t.Fatal(err)
+	}
+	if files := listFiles(t, dir); strings.Join(files, ",") != strings.Join(want, ",") {
+		t.Errorf("want files %v, got %v", want, files)
+	}
+	got, err := c.readConfigDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// read in the order of the file names
+	tickets := config.Projects[0].Tickets
+	config.Projects[0].Tickets = []*Ticket{tickets[2], tickets[1], tickets[0]}
+	assertConfig(t, "same file name", config, got)
+}
+
+func TestReadConfigDirDuplicateID(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(filepath.Join(dir, "aaaa"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"5-old-subject.md", "5-renamed-subject.md"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, "aaaa", name), []byte("---\nsubject: s\n---\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := testConverter().readConfigDir(dir)
+	if err == nil || !strings.Contains(err.Error(), "duplicate ID 5") {
+		t.Errorf("want the duplicate ID error, got %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package sync
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var dotColors = []string{"#aec7e8", "#ffbb78", "#98df8a", "#ff9896", "#c5b0d5", "#c49c94", "#f7b6d2", "#dbdb8d", "#9edae5"}
+
+const dotClosedColor = "#dddddd"
+
+func (c *Converter) SaveConfigDOT(writer io.Writer, config *Config) error {
+	w := bufio.NewWriter(writer)
+	fmt.Fprintln(w, "digraph redmine {")
+	fmt.Fprintln(w, "  rankdir=LR;")
+	fmt.Fprintln(w, `  node [shape=box, style="rounded,filled", fontname="sans-serif"];`)
+
+	ids := map[int]bool{}
+	order := []int{}
+	edges := []string{}
+	var writeNode func(t *Ticket, indent string) error
+	writeNode = func(t *Ticket, indent string) error {
+		ids[t.ID] = true
+		order = append(order, t.ID)
+		label := fmt.Sprintf("#%d", t.ID)
+		if t.Subject != nil {
+			label += " " + *t.Subject
+		}
+		if t.Assignee != nil && *t.Assignee != "" {
+			label += "\n" + *t.Assignee
+		}
+		color, err := c.statusColor(t.Status)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%si%d [label=%s, fillcolor=%s, URL=%s];\n", indent, t.ID, dotQuote(label), dotQuote(color), dotQuote(c.IssueURL(t.ID)))
+		for _, child := range t.Children {
+			edges = append(edges, fmt.Sprintf("i%d -> i%d;", t.ID, child.ID))
+			if err := writeNode(child, indent); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, p := range config.Projects {
+		name, err := c.Projects.FindNameByID(p.ID)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "  subgraph cluster_p%d {\n", p.ID)
+		fmt.Fprintf(w, "    label=%s;\n", dotQuote(name))
+		for _, t := range p.Tickets {
+			if err := writeNode(t, "    "); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintln(w, "  }")
+	}
+
+	if c.GraphRelations {
+		written := map[int]bool{}
+		for _, id := range order {
+			relations, err := c.relations(id)
+			if err != nil {
+				return err
+			}
+			for _, r := range relations {
+				// the relation is listed for both of the issues
+				if written[r.ID] || !ids[r.IssueID] || !ids[r.IssueToID] {
+					continue
+				}
+				written[r.ID] = true
+				edges = append(edges, fmt.Sprintf("i%d -> i%d [style=dashed, label=%s];", r.IssueID, r.IssueToID, dotQuote(r.RelationType)))
+			}
+		}
+	}
+	for _, e := range edges {
+		fmt.Fprintf(w, "  %s\n", e)
+	}
+	fmt.Fprintln(w, "}")
+	return w.Flush()
+}
+
+// statusColor returns the fill color of the status.
+// The closed statuses are grayed out and the others are colored by the order of the statuses.
+func (c *Converter) statusColor(status *string) (string, error) {
+	if status == nil {
+		return "white", nil
+	}
+	if _, err := c.ClosedStatuses.FindIDByName(*status); err == nil {
+		return dotClosedColor, nil
+	}
+	names, err := c.Statuses.list()
+	if err != nil {
+		return "", err
+	}
+	for i, n := range names {
+		if n == *status {
+			return dotColors[i%len(dotColors)], nil
+		}
+	}
+	return "white", nil
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\r\n", `\n`, "\n", `\n`).Replace(s) + `"`
+}
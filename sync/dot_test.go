@@ -0,0 +1,90 @@
+package sync
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSaveConfigDOT(t *testing.T) {
+	config := &Config{Projects: []*Project{
+		{ID: 1, Tickets: []*Ticket{
+			{ID: 1, Subject: stringPtr(`say "hi"`), Status: stringPtr("New"), Assignee: stringPtr("Alice A"), Children: []*Ticket{
+				{ID: 2, Subject: stringPtr("in progress"), Status: stringPtr("In Progress")},
+				{ID: 3, Subject: stringPtr("closed"), Status: stringPtr("Closed")},
+			}},
+		}},
+		{ID: 2, Tickets: []*Ticket{
+			{ID: 4, Subject: stringPtr("no status")},
+			{ID: 5, Subject: stringPtr("unknown status"), Status: stringPtr("Rejected")},
+		}},
+	}}
+	nodes := []string{
+		"digraph redmine {",
+		"  rankdir=LR;",
+		`  node [shape=box, style="rounded,filled", fontname="sans-serif"];`,
+		"  subgraph cluster_p1 {",
+		`    label="aaaa";`,
+		// the statuses are colored by the order and the closed statuses are grayed out
+		`    i1 [label="#1 say \"hi\"\nAlice A", fillcolor="#aec7e8", URL="http://redmine.example.com/issues/1"];`,
+		`    i2 [label="#2 in progress", fillcolor="#ffbb78", URL="http://redmine.example.com/issues/2"];`,
+		`    i3 [label="#3 closed", fillcolor="#dddddd", URL="http://redmine.example.com/issues/3"];`,
+		"  }",
+		"  subgraph cluster_p2 {",
+		`    label="a/b: c";`,
+		`    i4 [label="#4 no status", fillcolor="white", URL="http://redmine.example.com/issues/4"];`,
+		`    i5 [label="#5 unknown status", fillcolor="white", URL="http://redmine.example.com/issues/5"];`,
+		"  }",
+		"  i1 -> i2;",
+		"  i1 -> i3;",
+	}
+	relations := []Relation{
+		{ID: 1, IssueID: 2, IssueToID: 4, RelationType: RelationPrecedes},
+		{ID: 2, IssueID: 5, IssueToID: 1, RelationType: RelationBlocks},
+		// the issue out of the config is not drawn
+		{ID: 3, IssueID: 3, IssueToID: 9, RelationType: RelationRelates},
+	}
+	tests := []struct {
+		name      string
+		relations bool
+		edges     []string
+	}{
+		{"without the relations", false, nil},
+		{
+			"with the relations",
+			true,
+			[]string{
+				// in the order of the nodes, and once for the both issues
+				`  i5 -> i1 [style=dashed, label="blocks"];`,
+				`  i2 -> i4 [style=dashed, label="precedes"];`,
+			},
+		},
+	}
+	for _, test := range tests {
+		c := testConverter()
+		c.endpoint = "http://redmine.example.com/"
+		c.GraphRelations = test.relations
+		requested := []int{}
+		c.relations = func(issueID int) ([]Relation, error) {
+			requested = append(requested, issueID)
+			list := []Relation{}
+			for _, r := range relations {
+				if r.IssueID == issueID || r.IssueToID == issueID {
+					list = append(list, r)
+				}
+			}
+			return list, nil
+		}
+		var b bytes.Buffer
+		if err := c.SaveConfigDOT(&b, config); err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		lines := append(append(append([]string{}, nodes...), test.edges...), "}")
+		if want := strings.Join(lines, "\n") + "\n"; b.String() != want {
+			t.Errorf("%s: want\n%s\ngot\n%s", test.name, want, b.String())
+		}
+		if !test.relations && len(requested) != 0 {
+			t.Errorf("%s: want no relations requested, got %v", test.name, requested)
+		}
+	}
+}
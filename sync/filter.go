@@ -0,0 +1,129 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	redmine "github.com/uphy/go-redmine"
+)
+
+// IssueFilterOptions is the issue filter by the names.
+// Status, Tracker and Assignee accept multiple values separated by commas.
+type IssueFilterOptions struct {
+	Project string `yaml:"-"`
+	// Status is open, closed, all or the status names.
+	Status  string `yaml:"status"`
+	Tracker string `yaml:"tracker"`
+	// Assignee is none, or me and the user names.
+	Assignee string `yaml:"assignee"`
+	// UpdatedSince is the date or the date expression such as -7d.
+	UpdatedSince string `yaml:"updated_since"`
+	// IncludeSubprojects includes or excludes the issues of the subprojects, or follows the server setting if nil.
+	IncludeSubprojects *bool `yaml:"include_subprojects"`
+}
+
+// IssueFilter resolves the names of the options to the issue filter of the IDs.
+func (c *Converter) IssueFilter(o *IssueFilterOptions) (*redmine.IssueFilter, error) {
+	filter := &redmine.IssueFilter{}
+	if o.Project != "" {
+		id, err := c.Projects.FindIDByName(o.Project)
+		if err != nil {
+			return nil, err
+		}
+		filter.ProjectId = strconv.Itoa(id)
+	}
+	if o.IncludeSubprojects != nil {
+		if o.Project == "" {
+			return nil, errors.New("include-subprojects requires project")
+		}
+		if *o.IncludeSubprojects {
+			filter.SubprojectId = "*"
+		} else {
+			filter.SubprojectId = "!*"
+		}
+	}
+
+	switch o.Status {
+	case "":
+	case "open", "closed":
+		filter.StatusId = o.Status
+	case "all", "*":
+		filter.StatusId = "*"
+	default:
+		ids, err := filterIDs(c.Statuses, o.Status)
+		if err != nil {
+			return nil, err
+		}
+		filter.StatusId = ids
+	}
+
+	if o.Tracker != "" {
+		ids, err := filterIDs(c.Trackers, o.Tracker)
+		if err != nil {
+			return nil, err
+		}
+		filter.TrackerId = ids
+	}
+
+	switch o.Assignee {
+	case "":
+	case "none":
+		filter.AssignedToId = "!*"
+	default:
+		// the server resolves me in the list, but none is another operator
+		for _, name := range strings.Split(o.Assignee, ",") {
+			if strings.TrimSpace(name) == "none" {
+				return nil, errors.New("assignee none can't be combined with the other values")
+			}
+		}
+		ids, err := filterIDs(c.Users, o.Assignee, "me")
+		if err != nil {
+			return nil, err
+		}
+		filter.AssignedToId = ids
+	}
+
+	if o.UpdatedSince != "" {
+		now := time.Now()
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		date, err := c.resolveDate(o.UpdatedSince, today, func(name string) (time.Time, error) {
+			return time.Time{}, fmt.Errorf("unsupported date: %s", name)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid updated-since %q: %s", o.UpdatedSince, err)
+		}
+		filter.UpdatedOn = url.QueryEscape(">=" + date)
+	}
+	return filter, nil
+}
+
+// filterIDs resolves the names separated by commas to the IDs separated by '|'.
+// The keywords are written as they are instead of the IDs.
+// The result is escaped because the client adds the filter values to the URL as they are.
+func filterIDs(names *Names, values string, keywords ...string) (string, error) {
+	ids := []string{}
+	for _, name := range strings.Split(values, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		keyword := false
+		for _, k := range keywords {
+			keyword = keyword || name == k
+		}
+		if keyword {
+			ids = append(ids, name)
+			continue
+		}
+		id, err := names.FindIDByName(name)
+		if err != nil {
+			return "", err
+		}
+		ids = append(ids, strconv.Itoa(id))
+	}
+	return url.QueryEscape(strings.Join(ids, "|")), nil
+}
@@ -0,0 +1,31 @@
+package sync
+
+import (
+	"testing"
+)
+
+func TestIssueFilterAssignee(t *testing.T) {
+	c := testConverter()
+	tests := []struct {
+		assignee string
+		want     string
+	}{
+		{"me", "me"},
+		{"none", "!*"},
+		{"Alice A", "7"},
+		{"me, Alice A", "me%7C7"},
+		{"Alice A,me", "7%7Cme"},
+	}
+	for _, test := range tests {
+		filter, err := c.IssueFilter(&IssueFilterOptions{Assignee: test.assignee})
+		if err != nil {
+			t.Fatalf("%s: %s", test.assignee, err)
+		}
+		if filter.AssignedToId != test.want {
+			t.Errorf("%s: want %s, got %s", test.assignee, test.want, filter.AssignedToId)
+		}
+	}
+	if _, err := c.IssueFilter(&IssueFilterOptions{Assignee: "none,me"}); err == nil {
+		t.Error("want the error of none combined with the other values")
+	}
+}
@@ -0,0 +1,165 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Format reads and writes the config in a file format.
+// The formats are registered with RegisterFormat, and looked up by the name given with `--format` or by the
+// extension of the file, falling back to the format plugins on PATH.
+type Format interface {
+	// Name is the name of the format given with `--format`.
+	Name() string
+	// Extensions are the lower-cased file extensions with the leading dot such as ".yml".
+	Extensions() []string
+	Read(c *Converter, reader io.Reader) (*Config, error)
+	Write(c *Converter, writer io.Writer, config *Config) error
+}
+
+type (
+	ReadFunc  func(c *Converter, reader io.Reader) (*Config, error)
+	WriteFunc func(c *Converter, writer io.Writer, config *Config) error
+
+	funcFormat struct {
+		name       string
+		extensions []string
+		read       ReadFunc
+		write      WriteFunc
+	}
+)
+
+// NewFormat creates the format of the functions.
+// read can be nil for the export-only formats.
+func NewFormat(name string, extensions []string, read ReadFunc, write WriteFunc) Format {
+	return &funcFormat{name, extensions, read, write}
+}
+
+func (f *funcFormat) Name() string {
+	return f.name
+}
+
+func (f *funcFormat) Extensions() []string {
+	return f.extensions
+}
+
+func (f *funcFormat) CanRead() bool {
+	return f.read != nil
+}
+
+func (f *funcFormat) Read(c *Converter, reader io.Reader) (*Config, error) {
+	if f.read == nil {
+		return nil, errors.New("format does not support reading: " + f.name)
+	}
+	return f.read(c, reader)
+}
+
+func (f *funcFormat) Write(c *Converter, writer io.Writer, config *Config) error {
+	if f.write == nil {
+		return errors.New("format does not support writing: " + f.name)
+	}
+	return f.write(c, writer, config)
+}
+
+// CanRead reports whether the format supports reading, which is true unless the format has the CanRead method
+// returning false, such as the export-only formats of NewFormat.
+func CanRead(format Format) bool {
+	if f, ok := format.(interface {
+		CanRead() bool
+	}); ok {
+		return f.CanRead()
+	}
+	return true
+}
+
+// pathFormat is the format written to the path instead of the created file, such as the directories.
+type pathFormat interface {
+	writePath(c *Converter, name string, config *Config) error
+}
+
+var formats = []Format{}
+
+// RegisterFormat adds the format to the registry.
+// A format registered later overrides the name and the extensions of the former ones.
+func RegisterFormat(format Format) {
+	formats = append(formats, format)
+}
+
+// FindFormat returns the format of the name or the extension without the leading dot, such as "md".
+func FindFormat(name string) (Format, error) {
+	name = strings.ToLower(name)
+	for i := len(formats) - 1; i >= 0; i-- {
+		if formats[i].Name() == name {
+			return formats[i], nil
+		}
+	}
+	if format, err := FindFormatByExtension("." + name); err == nil {
+		return format, nil
+	}
+	if format, ok := findPluginFormat(name); ok {
+		return format, nil
+	}
+	names := FormatNames()
+	names = append(names, pluginNames(FormatPluginPrefix)...)
+	return nil, fmt.Errorf("unsupported format: %s, available formats: %v", name, names)
+}
+
+// FindFormatByExtension returns the format of the file extension such as ".yml".
+func FindFormatByExtension(ext string) (Format, error) {
+	ext = strings.ToLower(ext)
+	for i := len(formats) - 1; i >= 0; i-- {
+		for _, e := range formats[i].Extensions() {
+			if e == ext {
+				return formats[i], nil
+			}
+		}
+	}
+	if format, ok := findPluginFormat(strings.TrimPrefix(ext, ".")); ok {
+		return format, nil
+	}
+	return nil, errors.New("unsupported extension: " + ext)
+}
+
+// FormatOfFile returns the registered format of the extension of the file name.
+// The file without an extension is CSV.
+func FormatOfFile(name string) (Format, error) {
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext == "" {
+		return FindFormat("csv")
+	}
+	return FindFormatByExtension(ext)
+}
+
+// FormatNames returns the sorted names of the registered formats.
+// The format plugins are not included not to search PATH, which is done only when a format is not registered.
+func FormatNames() []string {
+	names := []string{}
+	seen := map[string]bool{}
+	for _, f := range formats {
+		if !seen[f.Name()] {
+			seen[f.Name()] = true
+			names = append(names, f.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	RegisterFormat(NewFormat("yaml", []string{".yaml", ".yml"}, (*Converter).readConfigYAML, (*Converter).SaveConfigYAML))
+	RegisterFormat(NewFormat("json", []string{".json"}, (*Converter).readConfigJSON, (*Converter).SaveConfigJSON))
+	RegisterFormat(NewFormat("markdown", []string{".md", ".markdown"}, (*Converter).readConfigMarkdown, (*Converter).SaveConfigMarkdown))
+	RegisterFormat(NewFormat("org", []string{".org"}, (*Converter).readConfigOrg, (*Converter).SaveConfigOrg))
+	RegisterFormat(NewFormat("csv", []string{".csv"}, (*Converter).readConfigCSV, (*Converter).SaveConfigCSV))
+	RegisterFormat(NewFormat("tsv", []string{".tsv"}, (*Converter).readConfigTSV, (*Converter).SaveConfigTSV))
+	RegisterFormat(NewFormat("xlsx", []string{".xlsx"}, (*Converter).readConfigXLSX, (*Converter).SaveConfigXLSX))
+	RegisterFormat(NewFormat("mermaid-gantt", []string{".mmd"}, nil, (*Converter).SaveConfigMermaidGantt))
+	RegisterFormat(NewFormat("plantuml-gantt", []string{".puml"}, nil, (*Converter).SaveConfigPlantUMLGantt))
+	RegisterFormat(NewFormat("dot", []string{".dot", ".gv"}, nil, (*Converter).SaveConfigDOT))
+	RegisterFormat(NewFormat("ics", []string{".ics"}, nil, (*Converter).SaveConfigICS))
+	RegisterFormat(dirFormat{})
+}
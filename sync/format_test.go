@@ -0,0 +1,95 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	redmine "github.com/uphy/go-redmine"
+)
+
+// fullTicket returns the ticket of all the fields.
+func fullTicket(id int, subject string, children ...*Ticket) *Ticket {
+	return &Ticket{ID: id, Subject: stringPtr(subject), Status: stringPtr("In Progress"), Tracker: stringPtr("Bug"),
+		Priority: stringPtr("Normal"), Assignee: stringPtr("Alice A"), DoneRatio: intPtr(30),
+		StartDate: stringPtr("2024-06-14"), DueDate: stringPtr("2024-06-20"), Duration: intPtr(5),
+		Description: stringPtr("line1\n\nline3"), Children: children}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	c := testConverter()
+	c.Projects.names = append(c.Projects.names, redmine.IdName{Id: 3, Name: "zzzz"})
+	// the formats which don't keep the durations
+	noDuration := map[string]bool{"markdown": true, "org": true}
+	tests := []struct {
+		name   string
+		config func() *Config
+	}{
+		{
+			"all fields",
+			func() *Config {
+				return &Config{Projects: []*Project{{ID: 1, Tickets: []*Ticket{fullTicket(5, "Subject")}}}}
+			},
+		},
+		{
+			"hierarchy",
+			func() *Config {
+				return &Config{Projects: []*Project{{ID: 1, Tickets: []*Ticket{
+					fullTicket(5, "parent", fullTicket(6, "child", fullTicket(7, "grandchild")), fullTicket(8, "second child")),
+					fullTicket(9, "second parent"),
+				}}}}
+			},
+		},
+		{
+			"projects",
+			func() *Config {
+				return &Config{Projects: []*Project{
+					{ID: 1, Tickets: []*Ticket{fullTicket(5, "x")}},
+					{ID: 3, Tickets: []*Ticket{fullTicket(6, "y")}},
+				}}
+			},
+		},
+	}
+	for _, format := range formats {
+		if !CanRead(format) {
+			continue
+		}
+		for _, test := range tests {
+			name := format.Name() + ": " + test.name
+			dir := tempDir(t)
+			defer os.RemoveAll(dir)
+			path := filepath.Join(dir, "issues")
+			if exts := format.Extensions(); len(exts) > 0 {
+				path += exts[0]
+			} else if err := os.Mkdir(path, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.SaveConfigFileAs(path, format, test.config()); err != nil {
+				t.Fatalf("%s: %s", name, err)
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := c.ReadConfig(f)
+			f.Close()
+			if err != nil {
+				t.Fatalf("%s: %s", name, err)
+			}
+			want := test.config()
+			if noDuration[format.Name()] {
+				var clear func(tickets []*Ticket)
+				clear = func(tickets []*Ticket) {
+					for _, t := range tickets {
+						t.Duration = nil
+						clear(t.Children)
+					}
+				}
+				for _, p := range want.Projects {
+					clear(p.Tickets)
+				}
+			}
+			assertConfig(t, name, want, got)
+		}
+	}
+}
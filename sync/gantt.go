@@ -0,0 +1,183 @@
+package sync
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+type (
+	ganttSection struct {
+		name  string
+		tasks []*ganttTask
+	}
+	ganttTask struct {
+		ticket    *Ticket
+		start     time.Time
+		due       time.Time
+		milestone bool
+		overdue   bool
+	}
+)
+
+// ganttSections collects the dated tickets into the sections.
+// The ticket with either of the dates spans over its duration, or is a milestone without the duration.
+// The tickets without parents belong to the section of the project, and the children belong to the section of
+// their parent, which is named after the path of the ancestors.
+func (c *Converter) ganttSections(config *Config, now time.Time) ([]*ganttSection, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	sections := []*ganttSection{}
+	var collect func(name string, tickets []*Ticket) error
+	collect = func(name string, tickets []*Ticket) error {
+		section := &ganttSection{name: name}
+		for _, t := range tickets {
+			start, err := parseDate(t.StartDate)
+			if err != nil {
+				return err
+			}
+			due, err := parseDate(t.DueDate)
+			if err != nil {
+				return err
+			}
+			if start.IsZero() && due.IsZero() {
+				continue
+			}
+			// the missing date is derived from the duration, or the ticket is a milestone on the other date
+			milestone := false
+			switch {
+			case !start.IsZero() && !due.IsZero():
+			case t.Duration != nil && *t.Duration > 0 && start.IsZero():
+				start = c.addWorkdays(due, 1-*t.Duration)
+			case t.Duration != nil && *t.Duration > 0:
+				due = c.addWorkdays(start, *t.Duration-1)
+			case start.IsZero():
+				start, milestone = due, true
+			default:
+				due, milestone = start, true
+			}
+			section.tasks = append(section.tasks, &ganttTask{
+				ticket:    t,
+				start:     start,
+				due:       due,
+				milestone: milestone,
+				// the same as the report, by the due date of the ticket
+				overdue: !c.isClosed(t.Status) && t.DueDate != nil && *t.DueDate != "" && due.Before(today),
+			})
+		}
+		if len(section.tasks) > 0 {
+			sections = append(sections, section)
+		}
+		for _, t := range tickets {
+			if len(t.Children) > 0 {
+				if err := collect(name+" / "+ganttName(t.Subject), t.Children); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	for _, p := range config.Projects {
+		name, err := c.Projects.FindNameByID(p.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := collect(name, p.Tickets); err != nil {
+			return nil, err
+		}
+	}
+	return sections, nil
+}
+
+func ganttName(subject *string) string {
+	if subject == nil {
+		return ""
+	}
+	// ':' and ';' separate the task attributes, and '#' starts a comment
+	return strings.NewReplacer(":", " ", ";", " ", "#", "").Replace(*subject)
+}
+
+func (c *Converter) SaveConfigMermaidGantt(writer io.Writer, config *Config) error {
+	sections, err := c.ganttSections(config, time.Now())
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(writer)
+	fmt.Fprintln(w, "gantt")
+	fmt.Fprintln(w, "    dateFormat YYYY-MM-DD")
+	for _, section := range sections {
+		fmt.Fprintf(w, "    section %s\n", ganttName(&section.name))
+		for _, task := range section.tasks {
+			tags := []string{}
+			if task.milestone {
+				tags = append(tags, "milestone")
+			}
+			if task.overdue {
+				tags = append(tags, "crit")
+			}
+			if task.ticket.DoneRatio != nil {
+				if *task.ticket.DoneRatio >= 100 {
+					tags = append(tags, "done")
+				} else if *task.ticket.DoneRatio > 0 {
+					tags = append(tags, "active")
+				}
+			}
+			tags = append(tags, fmt.Sprintf("t%d", task.ticket.ID))
+			// the end date of mermaid is exclusive
+			end := task.due.AddDate(0, 0, 1).Format("2006-01-02")
+			if task.milestone {
+				end = "0d"
+			}
+			fmt.Fprintf(w, "    %s :%s, %s, %s\n", ganttName(task.ticket.Subject), strings.Join(tags, ", "),
+				task.start.Format("2006-01-02"), end)
+		}
+	}
+	return w.Flush()
+}
+
+func (c *Converter) SaveConfigPlantUMLGantt(writer io.Writer, config *Config) error {
+	sections, err := c.ganttSections(config, time.Now())
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(writer)
+	fmt.Fprintln(w, "@startgantt")
+	var projectStart time.Time
+	for _, section := range sections {
+		for _, task := range section.tasks {
+			if projectStart.IsZero() || task.start.Before(projectStart) {
+				projectStart = task.start
+			}
+		}
+	}
+	if !projectStart.IsZero() {
+		fmt.Fprintf(w, "Project starts %s\n", projectStart.Format("2006-01-02"))
+	}
+	for _, section := range sections {
+		fmt.Fprintf(w, "-- %s --\n", section.name)
+		for _, task := range section.tasks {
+			name := fmt.Sprintf("[#%d %s]", task.ticket.ID, strings.NewReplacer("[", "(", "]", ")").Replace(ganttName(task.ticket.Subject)))
+			if task.milestone {
+				fmt.Fprintf(w, "%s happens %s\n", name, task.start.Format("2006-01-02"))
+			} else {
+				fmt.Fprintf(w, "%s starts %s and ends %s\n", name, task.start.Format("2006-01-02"), task.due.Format("2006-01-02"))
+			}
+			if !task.milestone && task.ticket.DoneRatio != nil && *task.ticket.DoneRatio > 0 {
+				fmt.Fprintf(w, "%s is %d%% completed\n", name, *task.ticket.DoneRatio)
+			}
+			if task.overdue {
+				fmt.Fprintf(w, "%s is colored in Red\n", name)
+			}
+		}
+	}
+	fmt.Fprintln(w, "@endgantt")
+	return w.Flush()
+}
+
+func parseDate(date *string) (time.Time, error) {
+	if date == nil || *date == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse("2006-01-02", *date)
+}
@@ -0,0 +1,59 @@
+package sync
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGanttSections(t *testing.T) {
+	c := testConverter()
+	now := time.Date(2024, 6, 20, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		ticket    *Ticket
+		start     string
+		due       string
+		milestone bool
+		overdue   bool
+	}{
+		{"both dates", &Ticket{ID: 1, StartDate: stringPtr("2024-06-10"), DueDate: stringPtr("2024-06-14")}, "2024-06-10", "2024-06-14", false, true},
+		{"closed", &Ticket{ID: 1, Status: stringPtr("Closed"), StartDate: stringPtr("2024-06-10"), DueDate: stringPtr("2024-06-14")}, "2024-06-10", "2024-06-14", false, false},
+		{"done but open", &Ticket{ID: 1, Status: stringPtr("New"), DoneRatio: intPtr(100), DueDate: stringPtr("2024-06-14")}, "2024-06-14", "2024-06-14", true, true},
+		{"due date only", &Ticket{ID: 1, DueDate: stringPtr("2024-06-28")}, "2024-06-28", "2024-06-28", true, false},
+		{"start date only", &Ticket{ID: 1, StartDate: stringPtr("2024-06-10")}, "2024-06-10", "2024-06-10", true, false},
+		{"start date and duration", &Ticket{ID: 1, StartDate: stringPtr("2024-06-14"), Duration: intPtr(3)}, "2024-06-14", "2024-06-18", false, false},
+		{"due date and duration", &Ticket{ID: 1, DueDate: stringPtr("2024-06-18"), Duration: intPtr(3)}, "2024-06-14", "2024-06-18", false, true},
+	}
+	for _, test := range tests {
+		config := &Config{Projects: []*Project{{ID: 1, Tickets: []*Ticket{test.ticket}}}}
+		sections, err := c.ganttSections(config, now)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		if len(sections) != 1 || len(sections[0].tasks) != 1 {
+			t.Fatalf("%s: want a task, got %v", test.name, sections)
+		}
+		task := sections[0].tasks[0]
+		if start := task.start.Format(dateLayout); start != test.start {
+			t.Errorf("%s: start: want %s, got %s", test.name, test.start, start)
+		}
+		if due := task.due.Format(dateLayout); due != test.due {
+			t.Errorf("%s: due: want %s, got %s", test.name, test.due, due)
+		}
+		if task.milestone != test.milestone {
+			t.Errorf("%s: milestone: want %v, got %v", test.name, test.milestone, task.milestone)
+		}
+		if task.overdue != test.overdue {
+			t.Errorf("%s: overdue: want %v, got %v", test.name, test.overdue, task.overdue)
+		}
+	}
+
+	config := &Config{Projects: []*Project{{ID: 1, Tickets: []*Ticket{{ID: 1, Subject: stringPtr("no dates")}}}}}
+	sections, err := c.ganttSections(config, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sections) != 0 {
+		t.Errorf("no dates: want no sections, got %v", sections)
+	}
+}
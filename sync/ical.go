@@ -0,0 +1,185 @@
+package sync
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+func (c *Converter) SaveConfigICS(writer io.Writer, config *Config) error {
+	host := "redmine-sync"
+	if u, err := url.Parse(c.endpoint); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+
+	w := bufio.NewWriter(writer)
+	writeLine := func(name string, value string) {
+		foldICSLine(w, name+":"+value)
+	}
+	writeLine("BEGIN", "VCALENDAR")
+	writeLine("VERSION", "2.0")
+	writeLine("PRODID", "-//redmine-sync//EN")
+	writeLine("CALSCALE", "GREGORIAN")
+
+	// assigned are the IDs of the tickets in the feed
+	assigned := []int{}
+	var writeTicket func(t *Ticket) error
+	writeTicket = func(t *Ticket) error {
+		for _, child := range t.Children {
+			if err := writeTicket(child); err != nil {
+				return err
+			}
+		}
+		if c.CalendarAssignee != "" && (t.Assignee == nil || *t.Assignee != c.CalendarAssignee) {
+			return nil
+		}
+		if t.ID != 0 {
+			assigned = append(assigned, t.ID)
+		}
+		start, err := parseDate(t.StartDate)
+		if err != nil {
+			return err
+		}
+		due, err := parseDate(t.DueDate)
+		if err != nil {
+			return err
+		}
+		if start.IsZero() && due.IsZero() {
+			return nil
+		}
+
+		description := []string{}
+		if t.Status != nil {
+			description = append(description, "Status: "+*t.Status)
+		}
+		if t.Assignee != nil && *t.Assignee != "" {
+			description = append(description, "Assignee: "+*t.Assignee)
+		}
+		if t.DoneRatio != nil {
+			description = append(description, fmt.Sprintf("Done: %d%%", *t.DoneRatio))
+		}
+		description = append(description, c.IssueURL(t.ID))
+		closed := c.isClosed(t.Status)
+
+		component := "VEVENT"
+		if c.CalendarTodo {
+			component = "VTODO"
+		}
+		writeLine("BEGIN", component)
+		writeLine("UID", fmt.Sprintf("issue-%d@%s", t.ID, host))
+		writeLine("DTSTAMP", stamp)
+		summary := ""
+		if t.Subject != nil {
+			summary = *t.Subject
+		}
+		writeLine("SUMMARY", escapeICSText(fmt.Sprintf("#%d %s", t.ID, summary)))
+		writeLine("DESCRIPTION", escapeICSText(strings.Join(description, "\n")))
+		writeLine("URL", c.IssueURL(t.ID))
+		if c.CalendarTodo {
+			if !start.IsZero() {
+				writeLine("DTSTART;VALUE=DATE", start.Format("20060102"))
+			}
+			if !due.IsZero() {
+				writeLine("DUE;VALUE=DATE", due.Format("20060102"))
+			}
+			if closed {
+				writeLine("STATUS", "COMPLETED")
+			} else {
+				writeLine("STATUS", "NEEDS-ACTION")
+			}
+			if t.DoneRatio != nil {
+				writeLine("PERCENT-COMPLETE", fmt.Sprint(*t.DoneRatio))
+			}
+		} else {
+			if start.IsZero() {
+				start = due
+			}
+			if due.IsZero() {
+				due = start
+			}
+			writeLine("DTSTART;VALUE=DATE", start.Format("20060102"))
+			// DTEND of all-day events is exclusive
+			writeLine("DTEND;VALUE=DATE", due.AddDate(0, 0, 1).Format("20060102"))
+			writeLine("TRANSP", "TRANSPARENT")
+		}
+		writeLine("END", component)
+		return nil
+	}
+	for _, p := range config.Projects {
+		for _, t := range p.Tickets {
+			if err := writeTicket(t); err != nil {
+				return err
+			}
+		}
+	}
+
+	// the feed of the assignee has only the versions of the assignee's tickets
+	var assignedVersions map[int]bool
+	if c.CalendarAssignee != "" {
+		issues, err := c.issues(assigned)
+		if err != nil {
+			return err
+		}
+		assignedVersions = map[int]bool{}
+		for _, issue := range issues {
+			if issue.FixedVersion != nil {
+				assignedVersions[issue.FixedVersion.Id] = true
+			}
+		}
+	}
+	writtenVersions := map[int]bool{}
+	for _, p := range config.Projects {
+		versions, err := c.versions(p.ID)
+		if err != nil {
+			return err
+		}
+		for _, v := range versions {
+			// shared versions are listed in every project
+			if v.DueDate == "" || writtenVersions[v.Id] || (assignedVersions != nil && !assignedVersions[v.Id]) {
+				continue
+			}
+			writtenVersions[v.Id] = true
+			due, err := time.Parse("2006-01-02", v.DueDate)
+			if err != nil {
+				return err
+			}
+			writeLine("BEGIN", "VEVENT")
+			writeLine("UID", fmt.Sprintf("version-%d@%s", v.Id, host))
+			writeLine("DTSTAMP", stamp)
+			writeLine("SUMMARY", escapeICSText(v.Project.Name+" "+v.Name))
+			writeLine("DESCRIPTION", escapeICSText(v.Description))
+			writeLine("URL", strings.TrimRight(c.endpoint, "/")+fmt.Sprintf("/versions/%d", v.Id))
+			writeLine("DTSTART;VALUE=DATE", due.Format("20060102"))
+			writeLine("DTEND;VALUE=DATE", due.AddDate(0, 0, 1).Format("20060102"))
+			writeLine("TRANSP", "TRANSPARENT")
+			writeLine("END", "VEVENT")
+		}
+	}
+	writeLine("END", "VCALENDAR")
+	return w.Flush()
+}
+
+func escapeICSText(s string) string {
+	return strings.NewReplacer("\\", "\\\\", ";", "\\;", ",", "\\,", "\r\n", "\\n", "\n", "\\n").Replace(s)
+}
+
+// foldICSLine writes the content line folded at 75 octets as required by RFC 5545.
+func foldICSLine(w io.Writer, line string) {
+	width := 0
+	var b strings.Builder
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > 75 {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	b.WriteString("\r\n")
+	io.WriteString(w, b.String())
+}
@@ -0,0 +1,197 @@
+package sync
+
+import (
+	"bytes"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	redmine "github.com/uphy/go-redmine"
+)
+
+// icsConverter returns the converter of the versions 1 to 3, where the issue 2 is fixed in the version 2.
+func icsConverter() *Converter {
+	c := testConverter()
+	c.endpoint = "http://redmine.example.com/"
+	c.versions = func(projectID int) ([]redmine.Version, error) {
+		return []redmine.Version{
+			{Id: 1, Name: "v1", DueDate: "2024-06-30", Project: redmine.IdName{Id: 1, Name: "aaaa"}},
+			{Id: 2, Name: "v2", DueDate: "2024-07-31", Project: redmine.IdName{Id: 1, Name: "aaaa"}, Description: "a, b"},
+			{Id: 3, Name: "v3", Project: redmine.IdName{Id: 1, Name: "aaaa"}},
+		}, nil
+	}
+	c.issues = func(ids []int) ([]redmine.Issue, error) {
+		issues := []redmine.Issue{}
+		for _, id := range ids {
+			issue := redmine.Issue{Id: id}
+			if id == 2 {
+				issue.FixedVersion = &redmine.IdName{Id: 2}
+			}
+			issues = append(issues, issue)
+		}
+		return issues, nil
+	}
+	return c
+}
+
+var icsStampPattern = regexp.MustCompile(`(?m)^DTSTAMP:\d{8}T\d{6}Z\r$`)
+
+// saveICS returns the calendar of the tickets with the time stamps replaced.
+func saveICS(t *testing.T, c *Converter, tickets ...*Ticket) string {
+	t.Helper()
+	var b bytes.Buffer
+	if err := c.SaveConfigICS(&b, &Config{Projects: []*Project{{ID: 1, Tickets: tickets}}}); err != nil {
+		t.Fatal(err)
+	}
+	return icsStampPattern.ReplaceAllString(b.String(), "DTSTAMP:STAMP\r")
+}
+
+func icsTickets() []*Ticket {
+	return []*Ticket{
+		{ID: 1, Subject: stringPtr("a; b, c"), Status: stringPtr("New"), Assignee: stringPtr("Alice A"), DoneRatio: intPtr(30),
+			StartDate: stringPtr("2024-06-10"), DueDate: stringPtr("2024-06-12")},
+		{ID: 2, Subject: stringPtr("due only"), Status: stringPtr("Closed"), DueDate: stringPtr("2024-06-14")},
+		{ID: 3, Subject: stringPtr("no dates"), Assignee: stringPtr("Alice A")},
+	}
+}
+
+func TestSaveConfigICSEvent(t *testing.T) {
+	want := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//redmine-sync//EN",
+		"CALSCALE:GREGORIAN",
+		"BEGIN:VEVENT",
+		"UID:issue-1@redmine.example.com",
+		"DTSTAMP:STAMP",
+		`SUMMARY:#1 a\; b\, c`,
+		`DESCRIPTION:Status: New\nAssignee: Alice A\nDone: 30%\nhttp://redmine.examp`,
+		" le.com/issues/1",
+		"URL:http://redmine.example.com/issues/1",
+		"DTSTART;VALUE=DATE:20240610",
+		"DTEND;VALUE=DATE:20240613",
+		"TRANSP:TRANSPARENT",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:issue-2@redmine.example.com",
+		"DTSTAMP:STAMP",
+		"SUMMARY:#2 due only",
+		`DESCRIPTION:Status: Closed\nhttp://redmine.example.com/issues/2`,
+		"URL:http://redmine.example.com/issues/2",
+		"DTSTART;VALUE=DATE:20240614",
+		"DTEND;VALUE=DATE:20240615",
+		"TRANSP:TRANSPARENT",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:version-1@redmine.example.com",
+		"DTSTAMP:STAMP",
+		"SUMMARY:aaaa v1",
+		"DESCRIPTION:",
+		"URL:http://redmine.example.com/versions/1",
+		"DTSTART;VALUE=DATE:20240630",
+		"DTEND;VALUE=DATE:20240701",
+		"TRANSP:TRANSPARENT",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:version-2@redmine.example.com",
+		"DTSTAMP:STAMP",
+		"SUMMARY:aaaa v2",
+		`DESCRIPTION:a\, b`,
+		"URL:http://redmine.example.com/versions/2",
+		"DTSTART;VALUE=DATE:20240731",
+		"DTEND;VALUE=DATE:20240801",
+		"TRANSP:TRANSPARENT",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+	if got := saveICS(t, icsConverter(), icsTickets()...); got != want {
+		t.Errorf("want\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestSaveConfigICSTodo(t *testing.T) {
+	c := icsConverter()
+	c.CalendarTodo = true
+	got := saveICS(t, c, icsTickets()...)
+	for _, want := range []string{
+		"BEGIN:VTODO\r\nUID:issue-1@redmine.example.com\r\n",
+		"DTSTART;VALUE=DATE:20240610\r\nDUE;VALUE=DATE:20240612\r\nSTATUS:NEEDS-ACTION\r\nPERCENT-COMPLETE:30\r\nEND:VTODO\r\n",
+		"URL:http://redmine.example.com/issues/2\r\nDUE;VALUE=DATE:20240614\r\nSTATUS:COMPLETED\r\nEND:VTODO\r\n",
+		// the versions are events
+		"BEGIN:VEVENT\r\nUID:version-1@redmine.example.com\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want %q in\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "DTEND;VALUE=DATE:20240613") {
+		t.Errorf("want no DTEND of the to-do, got\n%s", got)
+	}
+}
+
+func TestSaveConfigICSAssignee(t *testing.T) {
+	c := icsConverter()
+	c.CalendarAssignee = "Alice A"
+	var fetched []int
+	issues := c.issues
+	c.issues = func(ids []int) ([]redmine.Issue, error) {
+		fetched = ids
+		return issues(ids)
+	}
+	uidPattern := regexp.MustCompile(`(?m)^UID:(\S+)@`)
+	uids := func(ics string) []string {
+		list := []string{}
+		for _, m := range uidPattern.FindAllStringSubmatch(ics, -1) {
+			list = append(list, m[1])
+		}
+		return list
+	}
+
+	// the version of the other assignee's ticket is not written
+	got := saveICS(t, c, icsTickets()...)
+	if !reflect.DeepEqual(fetched, []int{1, 3}) {
+		t.Errorf("want the issues of the assignee fetched, got %v", fetched)
+	}
+	if want := []string{"issue-1"}; !reflect.DeepEqual(uids(got), want) {
+		t.Errorf("want %v, got %v", want, uids(got))
+	}
+
+	// the version of the assignee's undated ticket is written
+	c.issues = func(ids []int) ([]redmine.Issue, error) {
+		return []redmine.Issue{{Id: 1}, {Id: 3, FixedVersion: &redmine.IdName{Id: 2}}}, nil
+	}
+	got = saveICS(t, c, icsTickets()...)
+	if want := []string{"issue-1", "version-2"}; !reflect.DeepEqual(uids(got), want) {
+		t.Errorf("want %v, got %v", want, uids(got))
+	}
+}
+
+func TestFoldICSLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"short", "SUMMARY:a", "SUMMARY:a\r\n"},
+		{"75 octets", strings.Repeat("a", 75), strings.Repeat("a", 75) + "\r\n"},
+		{"76 octets", strings.Repeat("a", 76), strings.Repeat("a", 75) + "\r\n a\r\n"},
+		{"continuation lines", strings.Repeat("a", 150), strings.Repeat("a", 75) + "\r\n " + strings.Repeat("a", 74) + "\r\n a\r\n"},
+		// the 3 octets characters are not split at the 75th octet
+		{"multibyte", strings.Repeat("a", 73) + "あい", strings.Repeat("a", 73) + "\r\n あい\r\n"},
+	}
+	for _, test := range tests {
+		var b bytes.Buffer
+		foldICSLine(&b, test.line)
+		if b.String() != test.want {
+			t.Errorf("%s: want %q, got %q", test.name, test.want, b.String())
+		}
+	}
+}
+
+func TestEscapeICSText(t *testing.T) {
+	if got, want := escapeICSText("a\\b;c,d\r\ne\nf:g"), `a\\b\;c\,d\ne\nf:g`; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
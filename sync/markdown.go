@@ -0,0 +1,300 @@
+package sync
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Markdown task-list format.
+//
+//	# Project
+//
+//	- [ ] #123 Subject (@Alice, status: In Progress, due: 2024-05-01)
+//	  > description
+//	  - [x] #124 Child (status: Closed)
+var (
+	markdownProjectPattern = regexp.MustCompile(`^#\s+(.+?)\s*$`)
+	markdownItemPattern    = regexp.MustCompile(`^(\s*)[-*+]\s+\[([ xX])\]\s*(?:#(\d+)\s*)?(.*?)\s*$`)
+	markdownQuotePattern   = regexp.MustCompile(`^\s*>(?: (.*)|(.*))$`)
+	markdownAttrsPattern   = regexp.MustCompile(`^(.*?)\s*\(([^()]*)\)$`)
+)
+
+func (c *Converter) readConfigMarkdown(reader io.Reader) (*Config, error) {
+	type entry struct {
+		indent int
+		ticket *Ticket
+	}
+	config := &Config{}
+	var project *Project
+	var stack []entry
+	var description []string
+	var described *Ticket
+	flushDescription := func() {
+		if described != nil {
+			d := strings.Join(description, "\n")
+			described.Description = &d
+		}
+		described = nil
+		description = nil
+	}
+
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+	for scanner.Scan() {
+		line := scanner.Text()
+		lineNumber++
+		if m := markdownQuotePattern.FindStringSubmatch(line); m != nil && len(stack) > 0 {
+			if described == nil {
+				described = stack[len(stack)-1].ticket
+			}
+			description = append(description, m[1]+m[2])
+			continue
+		}
+		flushDescription()
+		if m := markdownProjectPattern.FindStringSubmatch(line); m != nil {
+			id, err := c.Projects.FindIDByName(m[1])
+			if err != nil {
+				return nil, err
+			}
+			project = config.findOrCreateProject(id)
+			stack = nil
+			continue
+		}
+		m := markdownItemPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if project == nil {
+			return nil, fmt.Errorf("line %d: ticket without project heading", lineNumber)
+		}
+		ticket, err := c.parseMarkdownItem(m[2] != " ", m[3], m[4])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", lineNumber, err)
+		}
+		indent := len(strings.Replace(m[1], "\t", "    ", -1))
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			project.Tickets = append(project.Tickets, ticket)
+		} else {
+			parent := stack[len(stack)-1].ticket
+			parent.Children = append(parent.Children, ticket)
+		}
+		stack = append(stack, entry{indent, ticket})
+	}
+	flushDescription()
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if _, err := c.toFlat(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func (c *Converter) parseMarkdownItem(checked bool, id string, text string) (*Ticket, error) {
+	t := &Ticket{}
+	if id != "" {
+		i, err := strconv.Atoi(id)
+		if err != nil {
+			return nil, err
+		}
+		t.ID = i
+	}
+	subject := text
+	if m := markdownAttrsPattern.FindStringSubmatch(text); m != nil {
+		if attrs, ok := parseMarkdownAttrs(m[2]); ok {
+			subject = m[1]
+			for k, v := range attrs {
+				value := v
+				switch k {
+				case "assignee":
+					t.Assignee = &value
+				case "status":
+					t.Status = &value
+				case "tracker":
+					t.Tracker = &value
+				case "priority":
+					t.Priority = &value
+				case "start":
+					t.StartDate = &value
+				case "due":
+					t.DueDate = &value
+				case "done":
+					ratio, err := strconv.Atoi(strings.TrimSuffix(value, "%"))
+					if err != nil {
+						return nil, fmt.Errorf("invalid done ratio: %s", value)
+					}
+					t.DoneRatio = &ratio
+				}
+			}
+		}
+	}
+	t.Subject = &subject
+
+	// the checkbox takes priority over the status attribute
+	closed := false
+	if t.Status != nil {
+		if _, err := c.ClosedStatuses.FindIDByName(*t.Status); err == nil {
+			closed = true
+		}
+	}
+	if checked && !closed {
+		if c.ClosedStatus == "" {
+			return nil, errors.New("checked ticket requires the closed status")
+		}
+		status := c.ClosedStatus
+		t.Status = &status
+	}
+	if !checked && closed {
+		status, err := c.openStatus()
+		if err != nil {
+			return nil, err
+		}
+		t.Status = &status
+	}
+	return t, nil
+}
+
+// openStatus returns the status set to the unchecked tickets which have the closed status.
+func (c *Converter) openStatus() (string, error) {
+	if c.OpenStatus != "" {
+		return c.OpenStatus, nil
+	}
+	if err := c.Statuses.initIfNeeded(); err != nil {
+		return "", err
+	}
+	for _, s := range c.Statuses.names {
+		if _, err := c.ClosedStatuses.FindIDByName(s.Name); err != nil {
+			return s.Name, nil
+		}
+	}
+	if c.Statuses.placeholder {
+		// the open status is unknown
+		return c.Statuses.FindNameByID(0)
+	}
+	return "", errors.New("unchecked ticket requires an open status")
+}
+
+// parseMarkdownAttrs parses the trailing attributes of an item.
+// ok is false if the parenthesized text is not an attribute list but a part of the subject.
+// The empty list is written after the subject which ends with an attribute list.
+func parseMarkdownAttrs(s string) (attrs map[string]string, ok bool) {
+	attrs = map[string]string{}
+	if strings.TrimSpace(s) == "" {
+		return attrs, true
+	}
+	for _, a := range strings.Split(s, ",") {
+		a = strings.TrimSpace(a)
+		if strings.HasPrefix(a, "@") {
+			attrs["assignee"] = strings.TrimPrefix(a, "@")
+			continue
+		}
+		kv := strings.SplitN(a, ":", 2)
+		if len(kv) != 2 {
+			return nil, false
+		}
+		key := strings.TrimSpace(kv[0])
+		switch key {
+		case "status", "tracker", "priority", "start", "due", "done":
+			attrs[key] = strings.TrimSpace(kv[1])
+		default:
+			return nil, false
+		}
+	}
+	return attrs, true
+}
+
+func (c *Converter) SaveConfigMarkdown(writer io.Writer, config *Config) error {
+	w := bufio.NewWriter(writer)
+	for i, p := range config.Projects {
+		name, err := c.Projects.FindNameByID(p.ID)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "# %s\n\n", name)
+		for _, t := range p.Tickets {
+			if err := c.writeMarkdownItem(w, t, 0); err != nil {
+				return err
+			}
+		}
+	}
+	return w.Flush()
+}
+
+// markdownHasAttrs reports whether the text ends with an attribute list, which must be followed by another one
+// to be read as a part of the subject.
+func markdownHasAttrs(text string) bool {
+	m := markdownAttrsPattern.FindStringSubmatch(text)
+	if m == nil {
+		return false
+	}
+	_, ok := parseMarkdownAttrs(m[2])
+	return ok
+}
+
+func (c *Converter) writeMarkdownItem(w io.Writer, t *Ticket, depth int) error {
+	indent := strings.Repeat("  ", depth)
+	check := " "
+	if t.Status != nil {
+		if _, err := c.ClosedStatuses.FindIDByName(*t.Status); err == nil {
+			check = "x"
+		}
+	}
+	line := fmt.Sprintf("%s- [%s] ", indent, check)
+	if t.ID != 0 {
+		line += fmt.Sprintf("#%d ", t.ID)
+	}
+	if t.Subject != nil {
+		line += *t.Subject
+	}
+
+	attrs := []string{}
+	if t.Assignee != nil && *t.Assignee != "" {
+		attrs = append(attrs, "@"+*t.Assignee)
+	}
+	addAttr := func(key string, value *string) {
+		if value != nil && *value != "" {
+			attrs = append(attrs, key+": "+*value)
+		}
+	}
+	addAttr("status", t.Status)
+	addAttr("tracker", t.Tracker)
+	addAttr("priority", t.Priority)
+	addAttr("start", t.StartDate)
+	addAttr("due", t.DueDate)
+	if t.DoneRatio != nil && *t.DoneRatio != 0 {
+		attrs = append(attrs, fmt.Sprintf("done: %d%%", *t.DoneRatio))
+	}
+	if len(attrs) > 0 || (t.Subject != nil && markdownHasAttrs(*t.Subject)) {
+		line += " (" + strings.Join(attrs, ", ") + ")"
+	}
+	if _, err := fmt.Fprintln(w, line); err != nil {
+		return err
+	}
+
+	if t.Description != nil && *t.Description != "" {
+		for _, l := range strings.Split(strings.Replace(*t.Description, "\r\n", "\n", -1), "\n") {
+			if l == "" {
+				fmt.Fprintf(w, "%s  >\n", indent)
+			} else {
+				fmt.Fprintf(w, "%s  > %s\n", indent, l)
+			}
+		}
+	}
+	for _, child := range t.Children {
+		if err := c.writeMarkdownItem(w, child, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
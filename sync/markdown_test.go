@@ -0,0 +1,76 @@
+package sync
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestConfigMarkdownSubject(t *testing.T) {
+	c := testConverter()
+	tests := []struct {
+		name   string
+		ticket *Ticket
+	}{
+		{
+			"subject ending with attributes",
+			&Ticket{ID: 5, Subject: stringPtr("rename (status: x)")},
+		},
+		{
+			"subject ending with attributes and the attributes",
+			&Ticket{ID: 5, Subject: stringPtr("rename (status: x)"), Status: stringPtr("New")},
+		},
+		{
+			"subject ending with parentheses",
+			&Ticket{ID: 5, Subject: stringPtr("call f()")},
+		},
+		{
+			"subject ending with a note",
+			&Ticket{ID: 5, Subject: stringPtr("fix (urgent)")},
+		},
+	}
+	for _, test := range tests {
+		config := &Config{Projects: []*Project{{ID: 1, Tickets: []*Ticket{test.ticket}}}}
+		var b bytes.Buffer
+		if err := c.SaveConfigMarkdown(&b, config); err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		got, err := c.readConfigMarkdown(&b)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		assertConfig(t, test.name, config, got)
+	}
+}
+
+func TestParseMarkdownItemCheckbox(t *testing.T) {
+	c := testConverter()
+	tests := []struct {
+		checked bool
+		text    string
+		want    string
+	}{
+		{true, "s", "Closed"},
+		{true, "s (status: New)", "Closed"},
+		{true, "s (status: Closed)", "Closed"},
+		{false, "s (status: Closed)", "New"},
+		{false, "s (status: In Progress)", "In Progress"},
+	}
+	for _, test := range tests {
+		ticket, err := c.parseMarkdownItem(test.checked, "", test.text)
+		if err != nil {
+			t.Fatalf("%v %q: %s", test.checked, test.text, err)
+		}
+		if ticket.Status == nil || *ticket.Status != test.want {
+			t.Errorf("%v %q: want status %s, got %v", test.checked, test.text, test.want, ticket.Status)
+		}
+	}
+
+	c.OpenStatus = "In Progress"
+	ticket, err := c.parseMarkdownItem(false, "", "s (status: Closed)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *ticket.Status != "In Progress" {
+		t.Errorf("want the open status option, got %s", *ticket.Status)
+	}
+}
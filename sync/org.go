@@ -0,0 +1,351 @@
+package sync
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Org-mode outline format.
+//
+//	#+TODO: NEW IN_PROGRESS | CLOSED
+//	* Project
+//	** NEW Subject
+//	SCHEDULED: <2018-07-17 Tue> DEADLINE: <2018-10-20 Sat>
+//	:PROPERTIES:
+//	:ID: 1
+//	:TRACKER: Docs
+//	:END:
+//	description
+//	*** NEW Child
+//
+// The tickets without the status have the NO_STATUS property not to read the first word of the subject as the
+// status, and the description lines which would be read as the other elements are escaped with a comma.
+var (
+	orgTodoPattern     = regexp.MustCompile(`^#\+(?:TODO|SEQ_TODO|TYP_TODO):\s*(.*)$`)
+	orgHeadingPattern  = regexp.MustCompile(`^(\*+)\s+(.*?)\s*$`)
+	orgPropertyPattern = regexp.MustCompile(`^\s*:([^:\s]+):\s*(.*?)\s*$`)
+	orgPlanningPattern = regexp.MustCompile(`(SCHEDULED|DEADLINE):\s*<(\d{4}-\d{2}-\d{2})[^>]*>`)
+)
+
+func (c *Converter) readConfigOrg(reader io.Reader) (*Config, error) {
+	config := &Config{}
+	var project *Project
+	// stack[i] is the ticket of the heading level i+2
+	var stack []*Ticket
+	var current *Ticket
+	var description []string
+	// escaped is the number of the description lines until the last escaped one, which are not trimmed
+	escaped := 0
+	inDrawer := false
+	keywords := map[string]bool{}
+	// titles are the headings of the tickets to read them again without the status
+	titles := map[*Ticket]string{}
+
+	flushDescription := func() {
+		if current != nil {
+			d := strings.Join(description[:escaped], "\n")
+			if rest := strings.TrimRight(strings.Join(description[escaped:], "\n"), "\n"); escaped == 0 {
+				d = rest
+			} else if rest != "" {
+				d += "\n" + rest
+			}
+			current.Description = &d
+		}
+		description = nil
+		escaped = 0
+	}
+
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+	for scanner.Scan() {
+		line := scanner.Text()
+		lineNumber++
+		if m := orgTodoPattern.FindStringSubmatch(line); m != nil {
+			for _, k := range strings.Fields(m[1]) {
+				if k != "|" {
+					keywords[k] = true
+				}
+			}
+			continue
+		}
+		if m := orgHeadingPattern.FindStringSubmatch(line); m != nil {
+			flushDescription()
+			inDrawer = false
+			level := len(m[1])
+			if level == 1 {
+				id, err := c.Projects.FindIDByName(m[2])
+				if err != nil {
+					return nil, err
+				}
+				project = config.findOrCreateProject(id)
+				stack = nil
+				current = nil
+				continue
+			}
+			if project == nil {
+				return nil, fmt.Errorf("line %d: ticket without project heading", lineNumber)
+			}
+			if level-2 > len(stack) {
+				return nil, fmt.Errorf("line %d: heading level skipped", lineNumber)
+			}
+			ticket, err := c.parseOrgHeading(m[2], keywords)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", lineNumber, err)
+			}
+			stack = stack[:level-2]
+			if len(stack) == 0 {
+				project.Tickets = append(project.Tickets, ticket)
+			} else {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, ticket)
+			}
+			stack = append(stack, ticket)
+			current = ticket
+			titles[ticket] = m[2]
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == ":PROPERTIES:":
+			inDrawer = true
+		case inDrawer && trimmed == ":END:":
+			inDrawer = false
+		case inDrawer:
+			if m := orgPropertyPattern.FindStringSubmatch(line); m != nil {
+				if strings.ToUpper(m[1]) == "NO_STATUS" {
+					title := titles[current]
+					current.Status = nil
+					current.Subject = &title
+					continue
+				}
+				if err := setOrgProperty(current, m[1], m[2]); err != nil {
+					return nil, fmt.Errorf("line %d: %s", lineNumber, err)
+				}
+			}
+		case len(description) == 0 && !strings.HasPrefix(line, ",") && orgPlanningPattern.MatchString(line):
+			for _, m := range orgPlanningPattern.FindAllStringSubmatch(line, -1) {
+				date := m[2]
+				if m[1] == "SCHEDULED" {
+					current.StartDate = &date
+				} else {
+					current.DueDate = &date
+				}
+			}
+		default:
+			if len(description) == 0 && trimmed == "" {
+				continue
+			}
+			if strings.HasPrefix(line, ",") && orgNeedsEscape(line[1:]) {
+				line = line[1:]
+				description = append(description, line)
+				escaped = len(description)
+				continue
+			}
+			description = append(description, line)
+		}
+	}
+	flushDescription()
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if _, err := c.toFlat(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func (c *Converter) parseOrgHeading(title string, keywords map[string]bool) (*Ticket, error) {
+	t := &Ticket{}
+	subject := title
+	fields := strings.SplitN(title, " ", 2)
+	if keywords[fields[0]] {
+		status, err := c.findStatusByKeyword(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		t.Status = &status
+		subject = ""
+		if len(fields) == 2 {
+			subject = strings.TrimSpace(fields[1])
+		}
+	}
+	t.Subject = &subject
+	return t, nil
+}
+
+func setOrgProperty(t *Ticket, key string, value string) error {
+	switch strings.ToUpper(key) {
+	case "ID":
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid id: %s", value)
+		}
+		t.ID = id
+	case "TRACKER":
+		t.Tracker = &value
+	case "PRIORITY":
+		t.Priority = &value
+	case "ASSIGNEE":
+		t.Assignee = &value
+	case "DONE_RATIO":
+		ratio, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid done ratio: %s", value)
+		}
+		t.DoneRatio = &ratio
+	}
+	return nil
+}
+
+// orgNeedsEscape reports whether the description line would be read as a heading, a keyword line, a planning line
+// or a property drawer, or would be dropped as a blank line at the start or the end of the description.
+// The blank lines are escaped only at the ends.
+func orgNeedsEscape(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(line, "*") || strings.HasPrefix(line, ",") || strings.HasPrefix(line, "#+") ||
+		orgPlanningPattern.MatchString(line) || trimmed == ":PROPERTIES:" || trimmed == ""
+}
+
+// orgKeyword converts the status name to a TODO keyword, which can't contain spaces.
+func orgKeyword(status string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(status), "_"))
+}
+
+func (c *Converter) findStatusByKeyword(keyword string) (string, error) {
+	if err := c.Statuses.initIfNeeded(); err != nil {
+		return "", err
+	}
+	for _, s := range c.Statuses.names {
+		if orgKeyword(s.Name) == keyword {
+			return s.Name, nil
+		}
+	}
+	if c.Statuses.placeholder {
+		// the keyword of the unknown status is read as the status name
+		return keyword, nil
+	}
+	return "", fmt.Errorf("no such status: %s", keyword)
+}
+
+func (c *Converter) SaveConfigOrg(writer io.Writer, config *Config) error {
+	if err := c.Statuses.initIfNeeded(); err != nil {
+		return err
+	}
+	open := []string{}
+	closed := []string{}
+	for _, s := range c.Statuses.names {
+		if _, err := c.ClosedStatuses.FindIDByName(s.Name); err == nil {
+			closed = append(closed, orgKeyword(s.Name))
+		} else {
+			open = append(open, orgKeyword(s.Name))
+		}
+	}
+
+	w := bufio.NewWriter(writer)
+	fmt.Fprintf(w, "#+TODO: %s | %s\n", strings.Join(open, " "), strings.Join(closed, " "))
+	for _, p := range config.Projects {
+		name, err := c.Projects.FindNameByID(p.ID)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "* %s\n", name)
+		for _, t := range p.Tickets {
+			if err := writeOrgHeading(w, t, 2); err != nil {
+				return err
+			}
+		}
+	}
+	return w.Flush()
+}
+
+func writeOrgHeading(w io.Writer, t *Ticket, level int) error {
+	title := []string{}
+	if t.Status != nil && *t.Status != "" {
+		title = append(title, orgKeyword(*t.Status))
+	}
+	if t.Subject != nil {
+		title = append(title, *t.Subject)
+	}
+	fmt.Fprintln(w, strings.Repeat("*", level)+" "+strings.Join(title, " "))
+
+	planning := []string{}
+	addPlanning := func(keyword string, date *string) error {
+		if date == nil || *date == "" {
+			return nil
+		}
+		d, err := time.Parse("2006-01-02", *date)
+		if err != nil {
+			return err
+		}
+		planning = append(planning, fmt.Sprintf("%s: <%s>", keyword, d.Format("2006-01-02 Mon")))
+		return nil
+	}
+	if err := addPlanning("SCHEDULED", t.StartDate); err != nil {
+		return err
+	}
+	if err := addPlanning("DEADLINE", t.DueDate); err != nil {
+		return err
+	}
+	if len(planning) > 0 {
+		fmt.Fprintln(w, strings.Join(planning, " "))
+	}
+
+	properties := []string{}
+	if t.ID != 0 {
+		properties = append(properties, fmt.Sprintf(":ID: %d", t.ID))
+	}
+	if t.Status == nil {
+		properties = append(properties, ":NO_STATUS: t")
+	}
+	addProperty := func(key string, value *string) {
+		if value != nil {
+			properties = append(properties, fmt.Sprintf(":%s: %s", key, *value))
+		}
+	}
+	addProperty("TRACKER", t.Tracker)
+	addProperty("PRIORITY", t.Priority)
+	addProperty("ASSIGNEE", t.Assignee)
+	if t.DoneRatio != nil {
+		properties = append(properties, fmt.Sprintf(":DONE_RATIO: %d", *t.DoneRatio))
+	}
+	if len(properties) > 0 {
+		fmt.Fprintln(w, ":PROPERTIES:")
+		for _, p := range properties {
+			fmt.Fprintln(w, p)
+		}
+		fmt.Fprintln(w, ":END:")
+	}
+
+	if t.Description != nil && *t.Description != "" {
+		lines := strings.Split(strings.Replace(*t.Description, "\r\n", "\n", -1), "\n")
+		first, last := len(lines), -1
+		for i, l := range lines {
+			if strings.TrimSpace(l) != "" {
+				if i < first {
+					first = i
+				}
+				last = i
+			}
+		}
+		for i, l := range lines {
+			if orgNeedsEscape(l) && (strings.TrimSpace(l) != "" || i < first || i > last) {
+				l = "," + l
+			}
+			fmt.Fprintln(w, l)
+		}
+	}
+	for _, child := range t.Children {
+		if err := writeOrgHeading(w, child, level+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package sync
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestConfigOrgAmbiguousText tests the subjects and the descriptions which can be read as the org syntax.
+func TestConfigOrgAmbiguousText(t *testing.T) {
+	c := testConverter()
+	tests := []struct {
+		name   string
+		ticket *Ticket
+	}{
+		{
+			"no status and the subject starting with a keyword",
+			&Ticket{ID: 5, Subject: stringPtr("NEW feature"), Description: stringPtr("")},
+		},
+		{
+			"no status and no subject",
+			&Ticket{ID: 5, Subject: stringPtr(""), Description: stringPtr("")},
+		},
+		{
+			"description lines read as the other elements",
+			&Ticket{ID: 5, Subject: stringPtr("s"), Status: stringPtr("New"),
+				Description: stringPtr("SCHEDULED: <2024-06-14 Fri>\n:PROPERTIES:\n:ID: 9\n:END:\n* heading\n,comma\n#+TODO: A | B")},
+		},
+		{
+			"planning line after the first line",
+			&Ticket{ID: 5, Subject: stringPtr("s"), Status: stringPtr("New"), Description: stringPtr("text\nDEADLINE: <2024-06-14 Fri>")},
+		},
+		{
+			"blank lines at the ends",
+			&Ticket{ID: 5, Subject: stringPtr("s"), Status: stringPtr("New"), Description: stringPtr("\n  \ntext\n\n")},
+		},
+	}
+	for _, test := range tests {
+		config := &Config{Projects: []*Project{{ID: 1, Tickets: []*Ticket{test.ticket}}}}
+		var b bytes.Buffer
+		if err := c.SaveConfigOrg(&b, config); err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		got, err := c.readConfigOrg(&b)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		assertConfig(t, test.name, config, got)
+	}
+}
+
+func TestReadConfigOrgKeyword(t *testing.T) {
+	src := "#+TODO: NEW IN_PROGRESS | CLOSED\n* aaaa\n** IN_PROGRESS Subject\n** Without status\n"
+	got, err := testConverter().readConfigOrg(bytes.NewBufferString(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Config{Projects: []*Project{{ID: 1, Tickets: []*Ticket{
+		{Subject: stringPtr("Subject"), Status: stringPtr("In Progress"), Description: stringPtr("")},
+		{Subject: stringPtr("Without status"), Description: stringPtr("")},
+	}}}}
+	assertConfig(t, "keyword", want, got)
+}
+
+func TestSaveConfigOrgNoStatus(t *testing.T) {
+	config := &Config{Projects: []*Project{{ID: 1, Tickets: []*Ticket{
+		{ID: 5, Subject: stringPtr("NEW feature"), Description: stringPtr("")},
+		{ID: 6, Subject: stringPtr("NEW feature"), Status: stringPtr("New"), Description: stringPtr("")},
+	}}}}
+	var b bytes.Buffer
+	if err := testConverter().SaveConfigOrg(&b, config); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(b.String(), ":NO_STATUS: t"); n != 1 {
+		t.Errorf("want the NO_STATUS property of the ticket without the status, got\n%s", b.String())
+	}
+}
@@ -0,0 +1,229 @@
+package sync
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// External plugins are the executables on PATH, discovered like git subcommands.
+// On Windows, the executables are the files of the extensions in PATHEXT, such as .exe and .bat.
+//
+// A format plugin "redmine-sync-format-<name>" is called as
+//
+//	redmine-sync-format-<name> write   # reads the config as JSON from stdin and writes the file to stdout
+//	redmine-sync-format-<name> read    # reads the file from stdin and writes the config as JSON to stdout
+//
+// and is available as `--format <name>` and by the extension ".<name>".
+//
+// A transform plugin "redmine-sync-transform-<name>" reads the config as JSON from stdin and writes the transformed
+// config as JSON to stdout before importing.
+// The transforms change only the pushed issues, not the file. The new tickets have the temporary negative IDs,
+// which must be kept to write the IDs of the created issues back to the file.
+//
+// The plugins get the Redmine endpoint in the REDMINE_ENDPOINT environment variable.
+const (
+	FormatPluginPrefix    = "redmine-sync-format-"
+	TransformPluginPrefix = "redmine-sync-transform-"
+)
+
+type pluginFormat struct {
+	name string
+	path string
+}
+
+// findPluginFormat returns the format plugin of the name on PATH.
+func findPluginFormat(name string) (Format, bool) {
+	if name == "" || strings.ContainsRune(name, filepath.Separator) {
+		return nil, false
+	}
+	path, err := exec.LookPath(FormatPluginPrefix + name)
+	if err != nil {
+		return nil, false
+	}
+	return &pluginFormat{name, path}, true
+}
+
+func (f *pluginFormat) Name() string {
+	return f.name
+}
+
+func (f *pluginFormat) Extensions() []string {
+	return []string{"." + f.name}
+}
+
+func (f *pluginFormat) Read(c *Converter, reader io.Reader) (*Config, error) {
+	var out bytes.Buffer
+	if err := c.runPlugin(f.path, reader, &out, "read"); err != nil {
+		return nil, err
+	}
+	return c.readConfigJSON(&out)
+}
+
+func (f *pluginFormat) Write(c *Converter, writer io.Writer, config *Config) error {
+	var in bytes.Buffer
+	if err := c.SaveConfigJSON(&in, config); err != nil {
+		return err
+	}
+	return c.runPlugin(f.path, &in, writer, "write")
+}
+
+// Transform applies the transform plugins of Transforms to the config in order.
+func (c *Converter) Transform(config *Config) (*Config, error) {
+	for _, name := range c.Transforms {
+		path, err := exec.LookPath(TransformPluginPrefix + name)
+		if err != nil {
+			return nil, fmt.Errorf("no such transform: %s", name)
+		}
+		var in, out bytes.Buffer
+		if err := c.SaveConfigJSON(&in, config); err != nil {
+			return nil, err
+		}
+		if err := c.runPlugin(path, &in, &out); err != nil {
+			return nil, err
+		}
+		transformed, err := c.readConfigJSON(&out)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", filepath.Base(path), err)
+		}
+		config = transformed
+	}
+	return config, nil
+}
+
+// transformImport applies the transforms to the config to import.
+// writeBack writes the IDs of the issues created from the transformed config back to the new tickets of the config,
+// and returns whether any ID is written.
+func (c *Converter) transformImport(config *Config) (transformed *Config, writeBack func() bool, err error) {
+	// the new tickets are marked with the temporary IDs to find them in the transformed config
+	sources := map[int]*Ticket{}
+	var mark func(tickets []*Ticket)
+	mark = func(tickets []*Ticket) {
+		for _, t := range tickets {
+			if t.ID == 0 {
+				t.ID = -len(sources) - 1
+				sources[t.ID] = t
+			}
+			mark(t.Children)
+		}
+	}
+	for _, p := range config.Projects {
+		mark(p.Tickets)
+	}
+	transformed, err = c.Transform(config)
+	for _, t := range sources {
+		t.ID = 0
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	created := map[*Ticket]*Ticket{}
+	var unmark func(tickets []*Ticket)
+	unmark = func(tickets []*Ticket) {
+		for _, t := range tickets {
+			if t.ID < 0 {
+				if source, ok := sources[t.ID]; ok {
+					created[t] = source
+				}
+				t.ID = 0
+			}
+			unmark(t.Children)
+		}
+	}
+	for _, p := range transformed.Projects {
+		unmark(p.Tickets)
+	}
+	return transformed, func() bool {
+		changed := false
+		for t, source := range created {
+			if t.ID != 0 && source.ID == 0 {
+				source.ID = t.ID
+				changed = true
+			}
+		}
+		return changed
+	}, nil
+}
+
+func (c *Converter) runPlugin(path string, stdin io.Reader, stdout io.Writer, args ...string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+	cmd.Env = append(os.Environ(), "REDMINE_ENDPOINT="+c.endpoint)
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %s: %s", filepath.Base(path), err, msg)
+		}
+		return fmt.Errorf("%s: %s", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// pluginNames returns the names of the plugin executables of the prefix on PATH.
+func pluginNames(prefix string) []string {
+	names := []string{}
+	exts := executableExts()
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, f := range files {
+			if f.IsDir() {
+				continue
+			}
+			name, ok := executableName(f.Name(), f.Mode(), exts)
+			if !ok || !strings.HasPrefix(name, prefix) {
+				continue
+			}
+			names = append(names, strings.TrimPrefix(name, prefix))
+		}
+	}
+	return names
+}
+
+// executableName returns the name of the executable file without the extension.
+// The files are executable by the extensions of exts on Windows, which has no executable bits, and by the mode
+// otherwise.
+func executableName(name string, mode os.FileMode, exts []string) (string, bool) {
+	if exts == nil {
+		if mode&0111 == 0 {
+			return "", false
+		}
+		return name, true
+	}
+	ext := filepath.Ext(name)
+	for _, e := range exts {
+		if ext != "" && strings.EqualFold(ext, e) {
+			return strings.TrimSuffix(name, ext), true
+		}
+	}
+	return "", false
+}
+
+// executableExts returns the extensions of the executables in PATHEXT on Windows, and nil on the other systems.
+func executableExts() []string {
+	if runtime.GOOS != "windows" {
+		return nil
+	}
+	pathext := os.Getenv("PATHEXT")
+	if pathext == "" {
+		pathext = ".com;.exe;.bat;.cmd"
+	}
+	exts := []string{}
+	for _, e := range filepath.SplitList(pathext) {
+		if e != "" {
+			exts = append(exts, e)
+		}
+	}
+	return exts
+}
@@ -0,0 +1,157 @@
+package sync
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// installPlugins writes the shell scripts of the names to a temporary directory added to PATH, and returns the
+// directory and the function restoring PATH.
+func installPlugins(t *testing.T, scripts map[string]string) (string, func()) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the plugins are shell scripts")
+	}
+	dir := tempDir(t)
+	for name, script := range scripts {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := os.Getenv("PATH")
+	os.Setenv("PATH", dir+string(filepath.ListSeparator)+path)
+	return dir, func() {
+		os.Setenv("PATH", path)
+		os.RemoveAll(dir)
+	}
+}
+
+func TestExecutableName(t *testing.T) {
+	exts := []string{".COM", ".EXE", ".BAT", ".CMD"}
+	tests := []struct {
+		name string
+		mode os.FileMode
+		exts []string
+		want string
+		ok   bool
+	}{
+		{"redmine-sync-format-a", 0755, nil, "redmine-sync-format-a", true},
+		{"redmine-sync-format-a", 0644, nil, "", false},
+		{"redmine-sync-format-a.exe", 0644, exts, "redmine-sync-format-a", true},
+		{"redmine-sync-format-a.Bat", 0644, exts, "redmine-sync-format-a", true},
+		{"redmine-sync-format-a.txt", 0755, exts, "", false},
+		{"redmine-sync-format-a", 0755, exts, "", false},
+	}
+	for _, test := range tests {
+		got, ok := executableName(test.name, test.mode, test.exts)
+		if got != test.want || ok != test.ok {
+			t.Errorf("%s %v %v: want %q %v, got %q %v", test.name, test.mode, test.exts, test.want, test.ok, got, ok)
+		}
+	}
+}
+
+func TestPluginFormat(t *testing.T) {
+	dir, restore := installPlugins(t, map[string]string{
+		FormatPluginPrefix + "test": `echo "$1 $REDMINE_ENDPOINT" >> "$(dirname "$0")/calls"` + "\ncat\n",
+		FormatPluginPrefix + "fail": "echo broken >&2\nexit 1\n",
+	})
+	defer restore()
+	c := testConverter()
+	c.endpoint = "http://redmine.example.com/"
+
+	found := false
+	for _, name := range pluginNames(FormatPluginPrefix) {
+		found = found || name == "test"
+	}
+	if !found {
+		t.Errorf("want the plugin test in %v", pluginNames(FormatPluginPrefix))
+	}
+	format, err := FormatOfFile("issues.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &Config{Projects: []*Project{{ID: 1, Tickets: []*Ticket{fullTicket(5, "a", fullTicket(6, "b"))}}}}
+	var b bytes.Buffer
+	if err := format.Write(c, &b, config); err != nil {
+		t.Fatal(err)
+	}
+	got, err := format.Read(c, &b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertConfig(t, "plugin", config, got)
+	calls, err := ioutil.ReadFile(filepath.Join(dir, "calls"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "write http://redmine.example.com/\nread http://redmine.example.com/\n"; string(calls) != want {
+		t.Errorf("want the calls %q, got %q", want, calls)
+	}
+
+	format, err = FindFormat("fail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := format.Write(c, &bytes.Buffer{}, config); err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("want the error of the plugin, got %v", err)
+	}
+}
+
+func TestTransformImport(t *testing.T) {
+	dir, restore := installPlugins(t, map[string]string{
+		// encloses the subjects in brackets
+		TransformPluginPrefix + "brackets": `tee "$(dirname "$0")/stdin" | sed 's/"subject": "\(.*\)"/"subject": "[\1]"/'` + "\n",
+	})
+	defer restore()
+	c := testConverter()
+	c.Transforms = []string{"brackets"}
+	newChild := &Ticket{Subject: stringPtr("b")}
+	newTicket := &Ticket{Subject: stringPtr("c")}
+	config := &Config{Projects: []*Project{{ID: 1, Tickets: []*Ticket{
+		{ID: 5, Subject: stringPtr("a"), Children: []*Ticket{newChild}},
+		newTicket,
+	}}}}
+	transformed, writeBack, err := c.transformImport(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdin, err := ioutil.ReadFile(filepath.Join(dir, "stdin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the new tickets are given to the plugin with the temporary IDs
+	if !strings.Contains(string(stdin), `"id": -1,`) || !strings.Contains(string(stdin), `"id": -2,`) {
+		t.Errorf("want the temporary IDs, got\n%s", stdin)
+	}
+	want := &Config{Projects: []*Project{{ID: 1, Tickets: []*Ticket{
+		{ID: 5, Subject: stringPtr("[a]"), Children: []*Ticket{{Subject: stringPtr("[b]")}}},
+		{Subject: stringPtr("[c]")},
+	}}}}
+	assertConfig(t, "transformed", want, transformed)
+	if newChild.ID != 0 || newTicket.ID != 0 {
+		t.Errorf("want the temporary IDs removed from the config, got %d and %d", newChild.ID, newTicket.ID)
+	}
+
+	if writeBack() {
+		t.Error("want no IDs written back before the import")
+	}
+	// the issues are created from the transformed tickets
+	transformed.Projects[0].Tickets[0].Children[0].ID = 10
+	transformed.Projects[0].Tickets[1].ID = 11
+	if !writeBack() {
+		t.Error("want the IDs written back")
+	}
+	if newChild.ID != 10 || newTicket.ID != 11 {
+		t.Errorf("want the created IDs 10 and 11, got %d and %d", newChild.ID, newTicket.ID)
+	}
+
+	c.Transforms = []string{"missing"}
+	if _, _, err := c.transformImport(config); err == nil || !strings.Contains(err.Error(), "no such transform: missing") {
+		t.Errorf("want the missing transform error, got %v", err)
+	}
+}
@@ -0,0 +1,197 @@
+package sync
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// LocalProfileFile is the name of the project-local profile file, which is searched from the working directory
+// up to the root.
+const LocalProfileFile = ".redmine-sync.yml"
+
+type (
+	// Profiles is the profile file.
+	//
+	//	default: staging
+	//	profiles:
+	//	  staging:
+	//	    endpoint: https://redmine-staging.example.com/
+	//	    apikey_command: pass show redmine/staging
+	//	    project: aaaa
+	//	    format: yaml
+	//	    filters:
+	//	      status: open
+	//	      assignee: me
+	Profiles struct {
+		// Default is the name of the profile used without --profile.
+		Default  string              `yaml:"default"`
+		Profiles map[string]*Profile `yaml:"profiles"`
+	}
+	// Profile is the settings of a Redmine server.
+	Profile struct {
+		Endpoint string `yaml:"endpoint"`
+		APIKey   string `yaml:"apikey"`
+		// APIKeyCommand is the shell command printing the API key, which is used if APIKey is empty.
+		// It is run by sh, or by cmd on Windows.
+		APIKeyCommand string `yaml:"apikey_command"`
+		// Project is the default project of the issue filters.
+		Project string `yaml:"project"`
+		// Format is the default format of export.
+		Format string `yaml:"format"`
+		// Filters is the default issue filters except the project.
+		Filters IssueFilterOptions `yaml:"filters"`
+	}
+)
+
+// ProfileFiles returns the profile files in the order of the precedence, the lowest first.
+// They are the user config file in the XDG config directory, and the project-local files from the root to the
+// working directory.
+func ProfileFiles() []string {
+	files := []string{}
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		if home := homeDir(); home != "" {
+			configHome = filepath.Join(home, ".config")
+		}
+	}
+	if configHome != "" {
+		files = append(files, filepath.Join(configHome, "redmine-sync", "config.yml"))
+	}
+	local := []string{}
+	if dir, err := os.Getwd(); err == nil {
+		for {
+			local = append([]string{filepath.Join(dir, LocalProfileFile)}, local...)
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				break
+			}
+			dir = parent
+		}
+	}
+	return append(files, local...)
+}
+
+// homeDir returns the home directory of the user, or the empty string if unknown.
+func homeDir() string {
+	if home := os.Getenv("HOME"); home != "" {
+		return home
+	}
+	if u, err := user.Current(); err == nil {
+		return u.HomeDir
+	}
+	return ""
+}
+
+// LoadProfiles reads the existing profile files.
+// The settings of the later files override the profiles of the same names field by field.
+// The project-local files can't set the endpoint and the key, not to send the key of the user to the server
+// chosen by the file of a repository.
+func LoadProfiles(files []string) (*Profiles, error) {
+	profiles := &Profiles{Profiles: map[string]*Profile{}}
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var p Profiles
+		if err := yaml.UnmarshalStrict(b, &p); err != nil {
+			return nil, fmt.Errorf("%s: %s", file, err)
+		}
+		if filepath.Base(file) == LocalProfileFile {
+			for name, profile := range p.Profiles {
+				if profile != nil && (profile.Endpoint != "" || profile.APIKey != "" || profile.APIKeyCommand != "") {
+					return nil, fmt.Errorf("%s: endpoint, apikey and apikey_command can't be set in the project-local file: %s", file, name)
+				}
+			}
+		}
+		if p.Default != "" {
+			profiles.Default = p.Default
+		}
+		for name, profile := range p.Profiles {
+			if profile == nil {
+				continue
+			}
+			if base, ok := profiles.Profiles[name]; ok {
+				base.merge(profile)
+			} else {
+				profiles.Profiles[name] = profile
+			}
+		}
+	}
+	return profiles, nil
+}
+
+// Profile returns the profile of the name, or the default profile for the empty name.
+// It returns the empty profile if no profile is selected.
+func (p *Profiles) Profile(name string) (*Profile, error) {
+	if name == "" {
+		name = p.Default
+	}
+	if name == "" {
+		return &Profile{}, nil
+	}
+	profile, ok := p.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("profile not found: %s", name)
+	}
+	return profile, nil
+}
+
+func (p *Profile) merge(o *Profile) {
+	for _, field := range []struct {
+		dst *string
+		src string
+	}{
+		{&p.Endpoint, o.Endpoint},
+		{&p.APIKey, o.APIKey},
+		{&p.APIKeyCommand, o.APIKeyCommand},
+		{&p.Project, o.Project},
+		{&p.Format, o.Format},
+		{&p.Filters.Status, o.Filters.Status},
+		{&p.Filters.Tracker, o.Filters.Tracker},
+		{&p.Filters.Assignee, o.Filters.Assignee},
+		{&p.Filters.UpdatedSince, o.Filters.UpdatedSince},
+	} {
+		if field.src != "" {
+			*field.dst = field.src
+		}
+	}
+	if o.Filters.IncludeSubprojects != nil {
+		p.Filters.IncludeSubprojects = o.Filters.IncludeSubprojects
+	}
+}
+
+// Key returns the API key of the profile, running the key command if needed.
+func (p *Profile) Key() (string, error) {
+	if p.APIKey != "" || p.APIKeyCommand == "" {
+		return p.APIKey, nil
+	}
+	var stderr bytes.Buffer
+	cmd := shellCommand(p.APIKeyCommand)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("apikey_command: %s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// shellCommand returns the command running the command line with the shell of the platform.
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
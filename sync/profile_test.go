@@ -0,0 +1,107 @@
+package sync
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadProfiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	user := filepath.Join(dir, "config.yml")
+	local := filepath.Join(dir, LocalProfileFile)
+	write := func(file string, content string) {
+		if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(user, "profiles:\n  a:\n    endpoint: https://a/\n    apikey: key\n    project: p1\n")
+
+	tests := []struct {
+		local   string
+		project string
+		err     string
+	}{
+		{"", "p1", ""},
+		{"profiles:\n  a:\n    project: p2\n", "p2", ""},
+		{"profiles:\n  a:\n    endpoint: https://evil/\n", "", "can't be set in the project-local file"},
+		{"profiles:\n  a:\n    apikey: key2\n", "", "can't be set in the project-local file"},
+		{"profiles:\n  a:\n    apikey_command: echo key\n", "", "can't be set in the project-local file"},
+	}
+	for _, test := range tests {
+		write(local, test.local)
+		profiles, err := LoadProfiles([]string{user, local})
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%q: want error %q, got %v", test.local, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := profiles.Profile("a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Endpoint != "https://a/" || p.APIKey != "key" || p.Project != test.project {
+			t.Errorf("%q: unexpected profile %+v", test.local, p)
+		}
+	}
+}
+
+func TestProfileKey(t *testing.T) {
+	tests := []struct {
+		profile Profile
+		want    string
+		err     bool
+	}{
+		{Profile{APIKey: "key"}, "key", false},
+		{Profile{APIKey: "key", APIKeyCommand: "echo other"}, "key", false},
+		{Profile{APIKeyCommand: "echo key2"}, "key2", false},
+		{Profile{APIKeyCommand: "exit 1"}, "", true},
+		{Profile{}, "", false},
+	}
+	for _, test := range tests {
+		got, err := test.profile.Key()
+		if test.err {
+			if err == nil {
+				t.Errorf("%+v: want an error, got %q", test.profile, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%+v: %s", test.profile, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%+v: want %q, got %q", test.profile, test.want, got)
+		}
+	}
+}
+
+func TestProfileFiles(t *testing.T) {
+	for _, env := range []string{"XDG_CONFIG_HOME", "HOME"} {
+		defer os.Setenv(env, os.Getenv(env))
+	}
+	os.Setenv("XDG_CONFIG_HOME", "")
+	os.Setenv("HOME", filepath.Join("home", "user"))
+	files := ProfileFiles()
+	if want := filepath.Join("home", "user", ".config", "redmine-sync", "config.yml"); len(files) == 0 || files[0] != want {
+		t.Errorf("want the user file %s, got %v", want, files)
+	}
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join("xdg"))
+	files = ProfileFiles()
+	if want := filepath.Join("xdg", "redmine-sync", "config.yml"); len(files) == 0 || files[0] != want {
+		t.Errorf("want the user file %s, got %v", want, files)
+	}
+	if got := files[len(files)-1]; filepath.Base(got) != LocalProfileFile {
+		t.Errorf("want the local file last, got %s", got)
+	}
+}
@@ -0,0 +1,98 @@
+package sync
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	redmine "github.com/uphy/go-redmine"
+)
+
+// Query is a saved issue query.
+type Query struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	IsPublic  bool   `json:"is_public"`
+	ProjectID *int   `json:"project_id"`
+}
+
+// ExportQuery exports the issues of the saved query, given by the name or the ID.
+// projectID scopes the query to the project if it is not 0, otherwise the project of the query is used.
+func (s *Sync) ExportQuery(query string, projectID int) (*Config, error) {
+	q, err := s.findQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if projectID == 0 && q.ProjectID != nil {
+		projectID = *q.ProjectID
+	}
+	if projectID == 0 {
+		issues, err := s.client.IssuesByQuery(q.ID)
+		if err != nil {
+			return nil, err
+		}
+		return s.Converter.Convert(issues)
+	}
+	// the client can't scope the query to a project
+	params := "query_id=" + strconv.Itoa(q.ID) + "&project_id=" + strconv.Itoa(projectID)
+	issues := []redmine.Issue{}
+	for {
+		var r struct {
+			Issues     []redmine.Issue `json:"issues"`
+			TotalCount int             `json:"total_count"`
+		}
+		if err := s.get("/issues.json?"+params+"&limit=100&offset="+strconv.Itoa(len(issues)), &r); err != nil {
+			return nil, err
+		}
+		issues = append(issues, r.Issues...)
+		if len(r.Issues) == 0 || len(issues) >= r.TotalCount {
+			break
+		}
+	}
+	return s.Converter.Convert(issues)
+}
+
+// findQuery finds the saved query visible to the user by the name or the ID.
+// The name shared by the queries is an error, and the ID must be given instead.
+func (s *Sync) findQuery(query string) (*Query, error) {
+	queries := []Query{}
+	for {
+		var r struct {
+			Queries    []Query `json:"queries"`
+			TotalCount int     `json:"total_count"`
+		}
+		if err := s.get("/queries.json?limit=100&offset="+strconv.Itoa(len(queries)), &r); err != nil {
+			return nil, err
+		}
+		queries = append(queries, r.Queries...)
+		if len(r.Queries) == 0 || len(queries) >= r.TotalCount {
+			break
+		}
+	}
+	var found *Query
+	ids := []string{}
+	for i := range queries {
+		if queries[i].Name == query {
+			found = &queries[i]
+			ids = append(ids, strconv.Itoa(queries[i].ID))
+		}
+	}
+	if len(ids) > 1 {
+		return nil, fmt.Errorf("ambiguous query name: %s (IDs: %s)", query, strings.Join(ids, ", "))
+	}
+	if found != nil {
+		return found, nil
+	}
+	if id, err := strconv.Atoi(query); err == nil {
+		for i := range queries {
+			if queries[i].ID == id {
+				return &queries[i], nil
+			}
+		}
+	}
+	names := []string{}
+	for _, q := range queries {
+		names = append(names, q.Name)
+	}
+	return nil, fmt.Errorf("query not found: %s (available: %s)", query, strings.Join(names, ", "))
+}
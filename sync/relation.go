@@ -0,0 +1,37 @@
+package sync
+
+import (
+	"strconv"
+
+	redmine "github.com/uphy/go-redmine"
+)
+
+const (
+	RelationRelates    = "relates"
+	RelationDuplicates = "duplicates"
+	RelationBlocks     = "blocks"
+	RelationPrecedes   = "precedes"
+	RelationFollows    = "follows"
+)
+
+// Relation is an issue relation.
+// redmine.IssueRelation can't be used because its issue IDs are declared as strings while the API returns numbers.
+type Relation struct {
+	ID           int    `json:"id"`
+	IssueID      int    `json:"issue_id"`
+	IssueToID    int    `json:"issue_to_id"`
+	RelationType string `json:"relation_type"`
+	Delay        *int   `json:"delay"`
+}
+
+func issueRelations(client *redmine.Client, endpoint string, apiKey string) func(issueID int) ([]Relation, error) {
+	return func(issueID int) ([]Relation, error) {
+		var r struct {
+			Relations []Relation `json:"relations"`
+		}
+		if err := getJSON(client, endpoint, apiKey, "/issues/"+strconv.Itoa(issueID)+"/relations.json", &r); err != nil {
+			return nil, err
+		}
+		return r.Relations, nil
+	}
+}
@@ -0,0 +1,266 @@
+package sync
+
+import (
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type (
+	report struct {
+		GeneratedAt string
+		Projects    []*reportProject
+		Statuses    []reportCount
+		Assignees   []reportCount
+		Overdue     []*reportTicket
+	}
+	reportProject struct {
+		Name      string
+		File      string
+		Tickets   []*reportTicket
+		Statuses  []reportCount
+		Assignees []reportCount
+		Overdue   []*reportTicket
+	}
+	reportTicket struct {
+		ID        int
+		Subject   string
+		Status    string
+		Assignee  string
+		StartDate string
+		DueDate   string
+		DoneRatio int
+		URL       string
+		Overdue   bool
+		Project   string
+		Children  []*reportTicket
+	}
+	reportCount struct {
+		Name  string
+		Count int
+	}
+)
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"counts": func(title string, counts []reportCount) interface{} {
+		return struct {
+			Title  string
+			Counts []reportCount
+		}{title, counts}
+	},
+}).Parse(`
+{{- define "head" -}}
+<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #333; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+th { background: #f0f0f0; }
+details { margin-left: 1.5em; }
+summary { cursor: pointer; }
+li.leaf { list-style: none; margin-left: 1.5em; }
+ul { padding-left: 0; }
+.overdue { color: #c00; font-weight: bold; }
+.meta { color: #777; font-size: 90%; }
+.bar { display: inline-block; width: 60px; height: 8px; background: #eee; vertical-align: middle; }
+.bar span { display: block; height: 8px; background: #6a6; }
+</style>
+</head>
+<body>
+{{- end -}}
+
+{{- define "counts" -}}
+<table>
+<tr><th>{{.Title}}</th><th>Tickets</th></tr>
+{{- range .Counts}}
+<tr><td>{{.Name}}</td><td>{{.Count}}</td></tr>
+{{- end}}
+</table>
+{{- end -}}
+
+{{- define "overdue" -}}
+{{- if . -}}
+<table>
+<tr><th>#</th><th>Subject</th><th>Project</th><th>Assignee</th><th>Status</th><th>Due Date</th></tr>
+{{- range .}}
+<tr><td><a href="{{.URL}}">#{{.ID}}</a></td><td>{{.Subject}}</td><td>{{.Project}}</td><td>{{.Assignee}}</td><td>{{.Status}}</td><td class="overdue">{{.DueDate}}</td></tr>
+{{- end}}
+</table>
+{{- else -}}
+<p>No overdue tickets.</p>
+{{- end -}}
+{{- end -}}
+
+{{- define "line" -}}
+<a href="{{.URL}}">#{{.ID}}</a> {{.Subject}}
+<span class="meta">{{.Status}}{{if .Assignee}} / {{.Assignee}}{{end}}{{if .DueDate}} / due <span{{if .Overdue}} class="overdue"{{end}}>{{.DueDate}}</span>{{end}}</span>
+<span class="bar" title="{{.DoneRatio}}%"><span style="width: {{.DoneRatio}}%"></span></span>
+{{- end -}}
+
+{{- define "ticket" -}}
+{{- if .Children -}}
+<li class="leaf"><details open><summary>{{template "line" .}}</summary>
+<ul>
+{{- range .Children}}
+{{template "ticket" .}}
+{{- end}}
+</ul>
+</details></li>
+{{- else -}}
+<li class="leaf">{{template "line" .}}</li>
+{{- end -}}
+{{- end -}}
+
+{{- define "index" -}}
+{{template "head" "Redmine Report"}}
+<h1>Redmine Report</h1>
+<p class="meta">Generated at {{.GeneratedAt}}</p>
+<h2>Projects</h2>
+<ul>
+{{- range .Projects}}
+<li class="leaf"><a href="{{.File}}">{{.Name}}</a></li>
+{{- end}}
+</ul>
+<h2>Status</h2>
+{{template "counts" (counts "Status" .Statuses)}}
+<h2>Assignee</h2>
+{{template "counts" (counts "Assignee" .Assignees)}}
+<h2>Overdue</h2>
+{{template "overdue" .Overdue}}
+</body>
+</html>
+{{end -}}
+
+{{- define "project" -}}
+{{template "head" .Name}}
+<p><a href="index.html">&laquo; Index</a></p>
+<h1>{{.Name}}</h1>
+<h2>Tickets</h2>
+<ul>
+{{- range .Tickets}}
+{{template "ticket" .}}
+{{- end}}
+</ul>
+<h2>Status</h2>
+{{template "counts" (counts "Status" .Statuses)}}
+<h2>Assignee</h2>
+{{template "counts" (counts "Assignee" .Assignees)}}
+<h2>Overdue</h2>
+{{template "overdue" .Overdue}}
+</body>
+</html>
+{{end -}}
+`))
+
+// SaveReportHTML writes the static HTML report of the config to the directory.
+func (c *Converter) SaveReportHTML(dir string, config *Config) error {
+	now := time.Now()
+	today := now.Format("2006-01-02")
+	r := &report{GeneratedAt: now.Format("2006-01-02 15:04")}
+	statuses := map[string]int{}
+	assignees := map[string]int{}
+	for _, p := range config.Projects {
+		name, err := c.Projects.FindNameByID(p.ID)
+		if err != nil {
+			return err
+		}
+		project := &reportProject{Name: name, File: "project-" + strconv.Itoa(p.ID) + ".html"}
+		projectStatuses := map[string]int{}
+		projectAssignees := map[string]int{}
+		var convert func(t *Ticket) *reportTicket
+		convert = func(t *Ticket) *reportTicket {
+			rt := &reportTicket{
+				ID:      t.ID,
+				URL:     c.IssueURL(t.ID),
+				Project: name,
+			}
+			copyString := func(dst *string, src *string) {
+				if src != nil {
+					*dst = *src
+				}
+			}
+			copyString(&rt.Subject, t.Subject)
+			copyString(&rt.Status, t.Status)
+			copyString(&rt.Assignee, t.Assignee)
+			copyString(&rt.StartDate, t.StartDate)
+			copyString(&rt.DueDate, t.DueDate)
+			if t.DoneRatio != nil {
+				rt.DoneRatio = *t.DoneRatio
+			}
+			rt.Overdue = !c.isClosed(t.Status) && rt.DueDate != "" && rt.DueDate < today
+			if rt.Overdue {
+				project.Overdue = append(project.Overdue, rt)
+			}
+			status := rt.Status
+			if status == "" {
+				status = "(none)"
+			}
+			assignee := rt.Assignee
+			if assignee == "" {
+				assignee = "(none)"
+			}
+			projectStatuses[status]++
+			projectAssignees[assignee]++
+			statuses[status]++
+			assignees[assignee]++
+			for _, child := range t.Children {
+				rt.Children = append(rt.Children, convert(child))
+			}
+			return rt
+		}
+		for _, t := range p.Tickets {
+			project.Tickets = append(project.Tickets, convert(t))
+		}
+		project.Statuses = reportCounts(projectStatuses)
+		project.Assignees = reportCounts(projectAssignees)
+		r.Overdue = append(r.Overdue, project.Overdue...)
+		r.Projects = append(r.Projects, project)
+	}
+	r.Statuses = reportCounts(statuses)
+	r.Assignees = reportCounts(assignees)
+	sort.Slice(r.Overdue, func(i, j int) bool {
+		return r.Overdue[i].DueDate < r.Overdue[j].DueDate
+	})
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	write := func(file string, name string, data interface{}) error {
+		f, err := os.Create(filepath.Join(dir, file))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return reportTemplate.ExecuteTemplate(f, name, data)
+	}
+	if err := write("index.html", "index", r); err != nil {
+		return err
+	}
+	for _, p := range r.Projects {
+		if err := write(p.File, "project", p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func reportCounts(m map[string]int) []reportCount {
+	counts := []reportCount{}
+	for name, count := range m {
+		counts = append(counts, reportCount{name, count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Name < counts[j].Name
+	})
+	return counts
+}
@@ -0,0 +1,194 @@
+package sync
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestSaveReportHTML(t *testing.T) {
+	c := testConverter()
+	c.endpoint = "http://redmine.example.com/"
+	// the due dates are in the past or in the far future to be independent of today
+	config := &Config{Projects: []*Project{
+		{ID: 1, Tickets: []*Ticket{
+			{ID: 1, Subject: stringPtr("parent <a>"), Status: stringPtr("New"), Assignee: stringPtr("Alice A"), DoneRatio: intPtr(50), DueDate: stringPtr("2000-01-02"), Children: []*Ticket{
+				{ID: 2, Subject: stringPtr("closed"), Status: stringPtr("Closed"), DoneRatio: intPtr(100), DueDate: stringPtr("2000-01-01")},
+				{ID: 3, Subject: stringPtr("future"), Status: stringPtr("New"), Assignee: stringPtr("Alice A"), DueDate: stringPtr("2999-12-31")},
+			}},
+		}},
+		{ID: 2, Tickets: []*Ticket{
+			{ID: 4, Subject: stringPtr("unassigned"), Status: stringPtr("In Progress"), DueDate: stringPtr("2000-01-01")},
+		}},
+	}}
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	if err := c.SaveReportHTML(dir, config); err != nil {
+		t.Fatal(err)
+	}
+
+	overdueHeader := `<tr><th>#</th><th>Subject</th><th>Project</th><th>Assignee</th><th>Status</th><th>Due Date</th></tr>`
+	overdue1 := `<tr><td><a href="http://redmine.example.com/issues/1">#1</a></td><td>parent &lt;a&gt;</td><td>aaaa</td><td>Alice A</td><td>New</td><td class="overdue">2000-01-02</td></tr>`
+	overdue4 := `<tr><td><a href="http://redmine.example.com/issues/4">#4</a></td><td>unassigned</td><td>a/b: c</td><td></td><td>In Progress</td><td class="overdue">2000-01-01</td></tr>`
+	tests := []struct {
+		file  string
+		title string
+		body  []string
+	}{
+		{
+			"index.html",
+			"Redmine Report",
+			[]string{
+				`<h1>Redmine Report</h1>`,
+				`<p class="meta">Generated at TIME</p>`,
+				`<h2>Projects</h2>`,
+				`<ul>`,
+				`<li class="leaf"><a href="project-1.html">aaaa</a></li>`,
+				`<li class="leaf"><a href="project-2.html">a/b: c</a></li>`,
+				`</ul>`,
+				`<h2>Status</h2>`,
+				`<table>`,
+				`<tr><th>Status</th><th>Tickets</th></tr>`,
+				`<tr><td>New</td><td>2</td></tr>`,
+				`<tr><td>Closed</td><td>1</td></tr>`,
+				`<tr><td>In Progress</td><td>1</td></tr>`,
+				`</table>`,
+				`<h2>Assignee</h2>`,
+				`<table>`,
+				`<tr><th>Assignee</th><th>Tickets</th></tr>`,
+				`<tr><td>(none)</td><td>2</td></tr>`,
+				`<tr><td>Alice A</td><td>2</td></tr>`,
+				`</table>`,
+				`<h2>Overdue</h2>`,
+				`<table>`,
+				overdueHeader,
+				// the overdue tickets of all the projects sorted by the due dates
+				overdue4,
+				overdue1,
+				`</table>`,
+			},
+		},
+		{
+			"project-1.html",
+			"aaaa",
+			[]string{
+				`<p><a href="index.html">&laquo; Index</a></p>`,
+				`<h1>aaaa</h1>`,
+				`<h2>Tickets</h2>`,
+				`<ul>`,
+				`<li class="leaf"><details open><summary><a href="http://redmine.example.com/issues/1">#1</a> parent &lt;a&gt;`,
+				`<span class="meta">New / Alice A / due <span class="overdue">2000-01-02</span></span>`,
+				`<span class="bar" title="50%"><span style="width: 50%"></span></span></summary>`,
+				`<ul>`,
+				`<li class="leaf"><a href="http://redmine.example.com/issues/2">#2</a> closed`,
+				`<span class="meta">Closed / due <span>2000-01-01</span></span>`,
+				`<span class="bar" title="100%"><span style="width: 100%"></span></span></li>`,
+				`<li class="leaf"><a href="http://redmine.example.com/issues/3">#3</a> future`,
+				`<span class="meta">New / Alice A / due <span>2999-12-31</span></span>`,
+				`<span class="bar" title="0%"><span style="width: 0%"></span></span></li>`,
+				`</ul>`,
+				`</details></li>`,
+				`</ul>`,
+				`<h2>Status</h2>`,
+				`<table>`,
+				`<tr><th>Status</th><th>Tickets</th></tr>`,
+				`<tr><td>New</td><td>2</td></tr>`,
+				`<tr><td>Closed</td><td>1</td></tr>`,
+				`</table>`,
+				`<h2>Assignee</h2>`,
+				`<table>`,
+				`<tr><th>Assignee</th><th>Tickets</th></tr>`,
+				`<tr><td>Alice A</td><td>2</td></tr>`,
+				`<tr><td>(none)</td><td>1</td></tr>`,
+				`</table>`,
+				`<h2>Overdue</h2>`,
+				`<table>`,
+				overdueHeader,
+				overdue1,
+				`</table>`,
+			},
+		},
+		{
+			"project-2.html",
+			"a/b: c",
+			[]string{
+				`<p><a href="index.html">&laquo; Index</a></p>`,
+				`<h1>a/b: c</h1>`,
+				`<h2>Tickets</h2>`,
+				`<ul>`,
+				`<li class="leaf"><a href="http://redmine.example.com/issues/4">#4</a> unassigned`,
+				`<span class="meta">In Progress / due <span class="overdue">2000-01-01</span></span>`,
+				`<span class="bar" title="0%"><span style="width: 0%"></span></span></li>`,
+				`</ul>`,
+				`<h2>Status</h2>`,
+				`<table>`,
+				`<tr><th>Status</th><th>Tickets</th></tr>`,
+				`<tr><td>In Progress</td><td>1</td></tr>`,
+				`</table>`,
+				`<h2>Assignee</h2>`,
+				`<table>`,
+				`<tr><th>Assignee</th><th>Tickets</th></tr>`,
+				`<tr><td>(none)</td><td>1</td></tr>`,
+				`</table>`,
+				`<h2>Overdue</h2>`,
+				`<table>`,
+				overdueHeader,
+				overdue4,
+				`</table>`,
+			},
+		},
+	}
+	generatedAt := regexp.MustCompile(`Generated at \d{4}-\d{2}-\d{2} \d{2}:\d{2}`)
+	for _, test := range tests {
+		b, err := ioutil.ReadFile(filepath.Join(dir, test.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		html := string(b)
+		if title := "<title>" + test.title + "</title>"; !strings.Contains(html, title) {
+			t.Errorf("%s: want %s", test.file, title)
+		}
+		// the head is the fixed style sheet
+		i := strings.Index(html, "<body>\n")
+		if i < 0 {
+			t.Fatalf("%s: no body in\n%s", test.file, html)
+		}
+		body := generatedAt.ReplaceAllString(html[i+len("<body>\n"):], "Generated at TIME")
+		want := strings.Join(test.body, "\n") + "\n</body>\n</html>\n"
+		if body != want {
+			t.Errorf("%s: want\n%s\ngot\n%s", test.file, want, body)
+		}
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != len(tests) {
+		t.Errorf("want %d files, got %d", len(tests), len(files))
+	}
+}
+
+func TestSaveReportHTMLNoOverdue(t *testing.T) {
+	config := &Config{Projects: []*Project{{ID: 1, Tickets: []*Ticket{
+		{ID: 1, Subject: stringPtr("closed"), Status: stringPtr("Closed"), DueDate: stringPtr("2000-01-01")},
+		{ID: 2, Subject: stringPtr("no due date"), Status: stringPtr("New")},
+	}}}}
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	if err := testConverter().SaveReportHTML(dir, config); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{"index.html", "project-1.html"} {
+		b, err := ioutil.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(b), "<h2>Overdue</h2>\n<p>No overdue tickets.</p>\n") {
+			t.Errorf("%s: want no overdue tickets, got\n%s", file, b)
+		}
+	}
+}
@@ -0,0 +1,349 @@
+package sync
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// ScheduleChange is the dates of the ticket before the scheduling.
+type ScheduleChange struct {
+	Ticket    *Ticket
+	StartDate string
+	DueDate   string
+}
+
+// schedule is the dates of the ticket.
+// scheduled is false for the dates in the file which are only used by the successors.
+type schedule struct {
+	start     time.Time
+	due       time.Time
+	scheduled bool
+}
+
+// LoadHolidays reads the holiday file, which has a date of YYYY-MM-DD at the beginning of each line.
+// The empty lines and the lines starting with '#' are ignored, and the rest of the line is a comment.
+func LoadHolidays(file string) (map[string]bool, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	holidays := map[string]bool{}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		date := strings.Fields(line)[0]
+		if _, err := time.Parse(dateLayout, date); err != nil || !datePattern.MatchString(date) {
+			return nil, fmt.Errorf("%s:%d: invalid date: %s", file, n, date)
+		}
+		holidays[date] = true
+	}
+	return holidays, scanner.Err()
+}
+
+// Schedule computes the start and due dates of the tickets with the durations, and of their ancestors.
+//
+// A ticket with a duration starts on the first working day after all of its predecessors, which are the
+// precedes, follows and blocks relations, with the delays of the relations.
+// The tickets without predecessors keep their start dates.
+// The start date of the parent is the lower bound of the children, and the parent spans over the scheduled
+// children.
+// The date expressions must be resolved with ResolveDates before the scheduling.
+func (c *Converter) Schedule(config *Config) ([]ScheduleChange, error) {
+	tickets, err := c.toFlat(config)
+	if err != nil {
+		return nil, err
+	}
+	byID := map[int]*Ticket{}
+	parents := map[*Ticket]*Ticket{}
+	for _, t := range tickets {
+		if t.ID != 0 {
+			byID[t.ID] = t
+		}
+		for _, child := range t.Children {
+			parents[child] = t
+		}
+	}
+
+	// the predecessors of the tickets with the delays
+	type predecessor struct {
+		ticket *Ticket
+		delay  int
+	}
+	predecessors := map[*Ticket][]predecessor{}
+	seen := map[int]bool{}
+	for _, t := range tickets {
+		if t.ID == 0 {
+			continue
+		}
+		relations, err := c.relations(t.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range relations {
+			if seen[r.ID] {
+				continue
+			}
+			seen[r.ID] = true
+			from, to := byID[r.IssueID], byID[r.IssueToID]
+			switch r.RelationType {
+			case RelationPrecedes, RelationBlocks:
+			case RelationFollows:
+				from, to = to, from
+			default:
+				continue
+			}
+			// the tickets out of the config are not rescheduled
+			if from == nil || to == nil {
+				continue
+			}
+			delay := 0
+			if r.Delay != nil {
+				delay = *r.Delay
+			}
+			predecessors[to] = append(predecessors[to], predecessor{from, delay})
+		}
+	}
+
+	schedules := map[*Ticket]*schedule{}
+	visiting := map[*Ticket]bool{}
+	var scheduleOf func(t *Ticket) (*schedule, error)
+	// bound returns the earliest start of the ticket, or the zero time if unbounded.
+	var bound func(t *Ticket) (time.Time, error)
+	bound = func(t *Ticket) (time.Time, error) {
+		var earliest time.Time
+		if parent := parents[t]; parent != nil {
+			b, err := bound(parent)
+			if err != nil {
+				return time.Time{}, err
+			}
+			earliest = b
+		}
+		if len(predecessors[t]) == 0 {
+			start, err := parseDate(t.StartDate)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("ticket #%d: invalid start date: %s", t.ID, *t.StartDate)
+			}
+			if start.After(earliest) {
+				earliest = start
+			}
+			return earliest, nil
+		}
+		for _, p := range predecessors[t] {
+			s, err := scheduleOf(p.ticket)
+			if err != nil {
+				return time.Time{}, err
+			}
+			if s == nil || s.due.IsZero() {
+				continue
+			}
+			next := c.addWorkdays(s.due, 1+p.delay)
+			if next.After(earliest) {
+				earliest = next
+			}
+		}
+		return earliest, nil
+	}
+	scheduleOf = func(t *Ticket) (*schedule, error) {
+		if s, ok := schedules[t]; ok {
+			return s, nil
+		}
+		if visiting[t] {
+			return nil, fmt.Errorf("dependency cycle at #%d", t.ID)
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		var s *schedule
+		if t.Duration != nil {
+			if *t.Duration < 1 {
+				return nil, fmt.Errorf("ticket #%d: duration must be positive: %d", t.ID, *t.Duration)
+			}
+			start, err := bound(t)
+			if err != nil {
+				return nil, err
+			}
+			if !start.IsZero() {
+				for !c.isWorkday(start) {
+					start = start.AddDate(0, 0, 1)
+				}
+				s = &schedule{start, c.addWorkdays(start, *t.Duration-1), true}
+			}
+		} else {
+			span := &schedule{}
+			for _, child := range t.Children {
+				cs, err := scheduleOf(child)
+				if err != nil {
+					return nil, err
+				}
+				if cs == nil {
+					continue
+				}
+				if cs.scheduled {
+					span.scheduled = true
+				}
+				if !cs.start.IsZero() && (span.start.IsZero() || cs.start.Before(span.start)) {
+					span.start = cs.start
+				}
+				if cs.due.After(span.due) {
+					span.due = cs.due
+				}
+			}
+			// the parent of the unscheduled children is not changed
+			if span.scheduled {
+				s = span
+			}
+		}
+		if s == nil {
+			// not scheduled, but the dates are used by the successors
+			start, err := parseDate(t.StartDate)
+			if err != nil {
+				return nil, fmt.Errorf("ticket #%d: invalid start date: %s", t.ID, *t.StartDate)
+			}
+			due, err := parseDate(t.DueDate)
+			if err != nil {
+				return nil, fmt.Errorf("ticket #%d: invalid due date: %s", t.ID, *t.DueDate)
+			}
+			if !due.IsZero() {
+				s = &schedule{start, due, false}
+			}
+		}
+		schedules[t] = s
+		return s, nil
+	}
+
+	// the dates are changed after all schedules are computed from the dates in the file
+	for _, t := range tickets {
+		if _, err := scheduleOf(t); err != nil {
+			return nil, err
+		}
+	}
+	changes := []ScheduleChange{}
+	for _, t := range tickets {
+		s := schedules[t]
+		if s == nil || !s.scheduled {
+			continue
+		}
+		start, due := s.start.Format(dateLayout), s.due.Format(dateLayout)
+		change := ScheduleChange{Ticket: t}
+		if t.StartDate != nil {
+			change.StartDate = *t.StartDate
+		}
+		if t.DueDate != nil {
+			change.DueDate = *t.DueDate
+		}
+		if change.StartDate == start && change.DueDate == due {
+			continue
+		}
+		t.StartDate = &start
+		t.DueDate = &due
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// ScheduleFile schedules the tickets of the file and writes the dates into the file.
+// push also updates the dates of the rescheduled issues, and of the issues with the resolved date expressions.
+// The other changes of the file, such as the new tickets, are not pushed.
+func (s *Sync) ScheduleFile(file string, push bool) ([]ScheduleChange, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	config, err := s.Converter.ReadConfig(f)
+	if err != nil {
+		return nil, err
+	}
+	tickets, err := s.Converter.toFlat(config)
+	if err != nil {
+		return nil, err
+	}
+	dates := map[*Ticket]ScheduleChange{}
+	for _, t := range tickets {
+		dates[t] = ticketDates(t)
+	}
+	resolved, err := s.Converter.ResolveDates(config, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	changes, err := s.Converter.Schedule(config)
+	if err != nil {
+		return nil, err
+	}
+	if resolved || len(changes) > 0 {
+		if err := s.Converter.SaveConfigFile(file, config); err != nil {
+			return nil, err
+		}
+	}
+	if push {
+		pushed := []*Ticket{}
+		for _, t := range tickets {
+			if t.ID != 0 && ticketDates(t) != dates[t] {
+				pushed = append(pushed, t)
+			}
+		}
+		if err := s.pushDates(file, pushed); err != nil {
+			return nil, err
+		}
+	}
+	return changes, nil
+}
+
+// ticketDates returns the current dates of the ticket as the change.
+func ticketDates(t *Ticket) ScheduleChange {
+	change := ScheduleChange{Ticket: t}
+	if t.StartDate != nil {
+		change.StartDate = *t.StartDate
+	}
+	if t.DueDate != nil {
+		change.DueDate = *t.DueDate
+	}
+	return change
+}
+
+// pushDates updates only the dates of the issues of the tickets, and of the tickets in the base snapshot of the
+// file if any.
+func (s *Sync) pushDates(file string, tickets []*Ticket) error {
+	for _, t := range tickets {
+		s.logger.Printf("Updating the dates of issue #%d...", t.ID)
+		dates := ticketDates(t)
+		body := map[string]interface{}{
+			"issue": map[string]string{"start_date": dates.StartDate, "due_date": dates.DueDate},
+		}
+		if err := s.put(fmt.Sprintf("/issues/%d.json", t.ID), body); err != nil {
+			return fmt.Errorf("failed to update the dates of issue #%d: %v", t.ID, err)
+		}
+	}
+	if len(tickets) == 0 {
+		return nil
+	}
+	if _, err := os.Stat(BaseFile(file)); os.IsNotExist(err) {
+		return nil
+	}
+	base, err := s.Converter.ReadBase(file)
+	if err != nil {
+		return err
+	}
+	baseTickets, err := s.Converter.toFlat(base)
+	if err != nil {
+		return err
+	}
+	byID := map[int]*Ticket{}
+	for _, t := range baseTickets {
+		byID[t.ID] = t
+	}
+	for _, t := range tickets {
+		if b, ok := byID[t.ID]; ok {
+			b.StartDate = t.StartDate
+			b.DueDate = t.DueDate
+		}
+	}
+	return s.Converter.SaveBase(file, base)
+}
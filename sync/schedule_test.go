@@ -0,0 +1,164 @@
+package sync
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSchedule(t *testing.T) {
+	c := testConverter()
+	config := &Config{Projects: []*Project{{ID: 1, Tickets: []*Ticket{
+		{Subject: stringPtr("parent"), Children: []*Ticket{
+			// 2024-06-14 is Friday
+			{Subject: stringPtr("a"), StartDate: stringPtr("2024-06-14"), Duration: intPtr(3)},
+			{Subject: stringPtr("b"), StartDate: stringPtr("2024-06-17"), Duration: intPtr(1)},
+		}},
+	}}}}
+	changes, err := c.Schedule(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 3 {
+		t.Errorf("want 3 changes, got %d", len(changes))
+	}
+	parent := config.Projects[0].Tickets[0]
+	for _, test := range []struct {
+		ticket     *Ticket
+		start, due string
+	}{
+		{parent, "2024-06-14", "2024-06-18"},
+		{parent.Children[0], "2024-06-14", "2024-06-18"},
+		{parent.Children[1], "2024-06-17", "2024-06-17"},
+	} {
+		if *test.ticket.StartDate != test.start || *test.ticket.DueDate != test.due {
+			t.Errorf("%s: want %s..%s, got %s..%s", *test.ticket.Subject, test.start, test.due, *test.ticket.StartDate, *test.ticket.DueDate)
+		}
+	}
+}
+
+func TestScheduleInvalidDate(t *testing.T) {
+	for _, ticket := range []*Ticket{
+		{Subject: stringPtr("s"), StartDate: stringPtr("+1d"), Duration: intPtr(1)},
+		{Subject: stringPtr("s"), DueDate: stringPtr("2024-13-01")},
+	} {
+		config := &Config{Projects: []*Project{{ID: 1, Tickets: []*Ticket{ticket}}}}
+		_, err := testConverter().Schedule(config)
+		if err == nil || !strings.Contains(err.Error(), "invalid") {
+			t.Errorf("want the invalid date error, got %v", err)
+		}
+	}
+}
+
+func TestScheduleFilePush(t *testing.T) {
+	requests := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+string(body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	s, err := New(server.URL, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Converter = testConverter()
+	s.Converter.relations = func(issueID int) ([]Relation, error) {
+		return nil, nil
+	}
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "issues.yml")
+	// 2024-06-14 is Friday
+	src := `projects:
+- id: 1
+  tickets:
+  - id: 10
+    subject: rescheduled
+    start_date: 2024-06-14
+    duration: 3
+  - id: 11
+    subject: unchanged
+    start_date: 2024-06-10
+    due_date: 2024-06-10
+  - id: 12
+    subject: expression
+    start_date: 2024-06-10
+    due_date: start + 1d
+  - subject: new
+    start_date: 2024-06-14
+    duration: 1
+`
+	if err := ioutil.WriteFile(file, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	base := &Config{Projects: []*Project{{ID: 1, Tickets: []*Ticket{
+		{ID: 10, Subject: stringPtr("rescheduled"), StartDate: stringPtr("2024-06-14")},
+		{ID: 11, Subject: stringPtr("unchanged"), StartDate: stringPtr("2024-06-10"), DueDate: stringPtr("2024-06-10")},
+		{ID: 12, Subject: stringPtr("old subject")},
+	}}}}
+	if err := s.Converter.SaveBase(file, base); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := s.ScheduleFile(file, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 {
+		t.Errorf("want the changes of #10 and the new ticket, got %d", len(changes))
+	}
+	// only the dates of the existing issues are pushed
+	dates := func(start, due string) string {
+		b, _ := json.Marshal(map[string]interface{}{"issue": map[string]string{"start_date": start, "due_date": due}})
+		return string(b)
+	}
+	want := []string{
+		"PUT /issues/10.json " + dates("2024-06-14", "2024-06-18"),
+		"PUT /issues/12.json " + dates("2024-06-10", "2024-06-11"),
+	}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("want the requests %v, got %v", want, requests)
+	}
+
+	// the new ticket is written only into the file
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	config, err := s.Converter.ReadConfig(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := config.Projects[0].Tickets[3]; n.ID != 0 || *n.StartDate != "2024-06-14" || *n.DueDate != "2024-06-14" {
+		t.Errorf("want the new ticket scheduled without the ID, got %s", mustJSON(t, n))
+	}
+	// the base snapshot gets only the pushed dates
+	saved, err := s.Converter.ReadBase(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantBase := &Config{Projects: []*Project{{ID: 1, Tickets: []*Ticket{
+		{ID: 10, Subject: stringPtr("rescheduled"), StartDate: stringPtr("2024-06-14"), DueDate: stringPtr("2024-06-18")},
+		{ID: 11, Subject: stringPtr("unchanged"), StartDate: stringPtr("2024-06-10"), DueDate: stringPtr("2024-06-10")},
+		{ID: 12, Subject: stringPtr("old subject"), StartDate: stringPtr("2024-06-10"), DueDate: stringPtr("2024-06-11")},
+	}}}}
+	assertConfig(t, "base", wantBase, saved)
+
+	// nothing is pushed without the changes
+	requests = nil
+	if _, err := s.ScheduleFile(file, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 0 {
+		t.Errorf("want no requests, got %v", requests)
+	}
+}
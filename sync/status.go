@@ -0,0 +1,153 @@
+package sync
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// baseFileSuffix is the suffix of the base snapshot file, which is written next to the synced file.
+const baseFileSuffix = ".base.yml"
+
+// Status is the unsynced changes of the file.
+type Status struct {
+	// Local is the changes from the base snapshot to the file.
+	Local []IssueChange
+	// Remote is the changes from the base snapshot to the server, or nil if not checked.
+	Remote []IssueChange
+}
+
+// BaseFile returns the base snapshot file of the synced file or directory.
+// It is the hidden file next to the file, or in the directory.
+func BaseFile(file string) string {
+	file = filepath.Clean(file)
+	if isDir(file) {
+		return filepath.Join(file, ".redmine-sync"+baseFileSuffix)
+	}
+	return filepath.Join(filepath.Dir(file), "."+filepath.Base(file)+baseFileSuffix)
+}
+
+// SaveBase records the config as the base snapshot of the file, which is the state of the last sync.
+func (c *Converter) SaveBase(file string, config *Config) error {
+	f, err := os.Create(BaseFile(file))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return c.SaveConfigYAML(f, config)
+}
+
+// ReadBase reads the base snapshot of the file.
+func (c *Converter) ReadBase(file string) (*Config, error) {
+	f, err := os.Open(BaseFile(file))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no base snapshot of %s: export with --output or import the file first", file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return c.readConfigYAML(f)
+}
+
+// Status compares the file with its base snapshot.
+// remote also compares the issues on the server with the base snapshot, filtered with the options.
+func (s *Sync) Status(file string, remote bool, options IssueFilterOptions) (*Status, error) {
+	base, err := s.Converter.ReadBase(file)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	config, err := s.Converter.ReadConfig(f)
+	if err != nil {
+		return nil, err
+	}
+	status := &Status{}
+	status.Local, err = DiffTickets(s.Converter, base, config)
+	if err != nil {
+		return nil, err
+	}
+	if remote {
+		// the server has the issues pushed with the transforms
+		pushed, err := s.Converter.Transform(base)
+		if err != nil {
+			return nil, err
+		}
+		status.Remote, err = s.DiffServer(pushed, options)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// WriteText writes the status like git status.
+func (st *Status) WriteText(w io.Writer, file string, color bool) {
+	paint := func(c string, line string) string {
+		if !color {
+			return line
+		}
+		return c + line + colorReset
+	}
+	describe := func(t *Ticket) string {
+		subject := ""
+		if t.Subject != nil {
+			subject = *t.Subject
+		}
+		if t.ID == 0 {
+			return subject
+		}
+		return fmt.Sprintf("#%d %s", t.ID, subject)
+	}
+
+	if len(st.Local) == 0 {
+		fmt.Fprintf(w, "%s: no local changes since the last sync\n", file)
+	} else {
+		fmt.Fprintf(w, "%s: local changes since the last sync:\n", file)
+		for _, change := range st.Local {
+			switch change.Change {
+			case ChangeAdded:
+				fmt.Fprintln(w, paint(colorGreen, "  added:    "+describe(change.Ticket2)))
+			case ChangeRemoved:
+				fmt.Fprintln(w, paint(colorRed, "  removed:  "+describe(change.Ticket1)))
+			case ChangeUpdated:
+				fields := []string{}
+				for _, f := range DiffFields(change.Ticket1, change.Ticket2) {
+					fields = append(fields, f.Field)
+				}
+				fmt.Fprintln(w, paint(colorCyan, fmt.Sprintf("  modified: %s (%s)", describe(change.Ticket2), strings.Join(fields, ", "))))
+			}
+		}
+	}
+
+	if st.Remote == nil {
+		return
+	}
+	if len(st.Remote) == 0 {
+		fmt.Fprintln(w, "server: up to date with the last sync")
+		return
+	}
+	fmt.Fprintln(w, "server: changed since the last sync:")
+	for _, change := range st.Remote {
+		switch change.Change {
+		case ChangeAdded:
+			// the ticket of the base is not on the server
+			fmt.Fprintln(w, paint(colorRed, "  deleted:  "+describe(change.Ticket2)))
+		case ChangeRemoved:
+			// the issue on the server is not in the base
+			fmt.Fprintln(w, paint(colorGreen, "  new:      "+describe(change.Ticket1)))
+		case ChangeUpdated:
+			fields := []string{}
+			for _, f := range syncedFieldChanges(change.Ticket1, change.Ticket2) {
+				fields = append(fields, f.Field)
+			}
+			fmt.Fprintln(w, paint(colorCyan, fmt.Sprintf("  modified: %s (%s)", describe(change.Ticket1), strings.Join(fields, ", "))))
+		}
+	}
+}
@@ -3,6 +3,9 @@ package sync
 import (
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"log"
 
@@ -14,6 +17,12 @@ type Sync struct {
 	client    *redmine.Client
 	Converter *Converter
 	logger    *log.Logger
+	endpoint  string
+	apiKey    string
+
+	// trackers and members are the caches of the projects by the IDs used by the validation
+	trackers map[int]map[int]bool
+	members  map[int]map[int]bool
 }
 
 func New(endpoint string, apiKey string) (*Sync, error) {
@@ -21,8 +30,10 @@ func New(endpoint string, apiKey string) (*Sync, error) {
 	logger := log.New(os.Stderr, "[sync]", log.LstdFlags|log.Lmicroseconds)
 	return &Sync{
 		client:    client,
-		Converter: newConverter(client),
+		Converter: newConverter(client, endpoint, apiKey),
 		logger:    logger,
+		endpoint:  endpoint,
+		apiKey:    apiKey,
 	}, nil
 }
 
@@ -48,34 +59,44 @@ func (s *Sync) Watch(file string, ignoreImportError bool) error {
 		return err
 	}
 	defer w.Close()
-	if err := w.Add(file); err != nil {
+	dir := isDir(file)
+	if dir {
+		if err := watchDir(w, file); err != nil {
+			return err
+		}
+	} else if err := w.Add(file); err != nil {
 		return err
 	}
 
-	fileForRead, err := os.Open(file)
-	if err != nil {
-		return err
+	readConfig := func() (*Config, error) {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return s.Converter.ReadConfig(f)
 	}
-	defer fileForRead.Close()
-
-	config, err := s.Converter.ReadConfig(fileForRead)
+	config, err := readConfig()
 	if err != nil {
 		return err
 	}
 	for evt := range w.Events {
-		if evt.Op != fsnotify.Write {
+		if dir && evt.Op&fsnotify.Create != 0 && isDir(evt.Name) {
+			if err := watchDir(w, evt.Name); err != nil {
+				return err
+			}
 			continue
 		}
-		s.logger.Println("Detected file modification.")
-		if _, err := fileForRead.Seek(0, 0); err != nil {
-			return err
+		if evt.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) == 0 || (!dir && evt.Op != fsnotify.Write) {
+			continue
 		}
-		config2, err := s.Converter.ReadConfig(fileForRead)
+		s.logger.Println("Detected file modification.")
+		config2, err := readConfig()
 		if err != nil {
 			return err
 		}
 		s.logger.Println("Importing the changes...")
-		changed, err := s.Import(config2, config)
+		changed, err := s.importTransformed(config2, config)
 		if err != nil {
 			if ignoreImportError {
 				s.logger.Printf("Failed to import: %s", err)
@@ -85,14 +106,12 @@ func (s *Sync) Watch(file string, ignoreImportError bool) error {
 		}
 		if changed {
 			s.logger.Println("Rewriting the config file...")
-			f, err := os.Create(file)
-			if err != nil {
-				return err
-			}
-			if err := s.Converter.SaveConfig(f, config2); err != nil {
+			if err := s.Converter.SaveConfigFile(file, config2); err != nil {
 				return err
 			}
-			f.Close()
+		}
+		if err := s.Converter.SaveBase(file, config2); err != nil {
+			return err
 		}
 		config = config2
 		s.logger.Println("Successfully applied the changes.")
@@ -100,13 +119,33 @@ func (s *Sync) Watch(file string, ignoreImportError bool) error {
 	return nil
 }
 
+// watchDir adds the directory and its sub directories to the watcher.
+func watchDir(w *fsnotify.Watcher, dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if path != dir && strings.HasPrefix(info.Name(), ".") {
+			return filepath.SkipDir
+		}
+		return w.Add(path)
+	})
+}
+
 func (s *Sync) Import(config *Config, base *Config) (changed bool, err error) {
+	// the dates are resolved and validated before any request not to fail in the middle of the import
+	changed, err = s.Converter.ResolveDates(config, time.Now())
+	if err != nil {
+		return false, err
+	}
 	changes, err := DiffTickets(s.Converter, base, config)
 	if err != nil {
 		return false, err
 	}
 
-	changed = false
 	for _, change := range changes {
 		switch change.Change {
 		case ChangeAdded, ChangeUpdated:
@@ -123,7 +162,9 @@ func (s *Sync) Import(config *Config, base *Config) (changed bool, err error) {
 	return
 }
 
-func (s *Sync) ImportFile(file *os.File, base *os.File) (config *Config, changed bool, err error) {
+// ImportFile imports the config read from the file.
+// base is the config of the last import, which can be nil.
+func (s *Sync) ImportFile(file io.Reader, base io.Reader) (config *Config, changed bool, err error) {
 	var configBase *Config
 	if base != nil {
 		c, err := s.Converter.ReadConfig(base)
@@ -139,13 +180,54 @@ func (s *Sync) ImportFile(file *os.File, base *os.File) (config *Config, changed
 		return nil, false, err
 	}
 
-	changed, err = s.Import(config, configBase)
+	changed, err = s.importTransformed(config, configBase)
 	if err != nil {
 		return nil, false, err
 	}
 	return config, changed, err
 }
 
+// importTransformed imports the config and the base transformed with the transforms.
+// The transforms change only the pushed issues, and the config gets only the resolved dates and the created IDs.
+func (s *Sync) importTransformed(config *Config, base *Config) (changed bool, err error) {
+	if len(s.Converter.Transforms) == 0 {
+		return s.Import(config, base)
+	}
+	changed, err = s.Converter.ResolveDates(config, time.Now())
+	if err != nil {
+		return false, err
+	}
+	if len(base.Projects) > 0 {
+		base, err = s.Converter.Transform(base)
+		if err != nil {
+			return false, err
+		}
+	}
+	transformed, writeBack, err := s.Converter.transformImport(config)
+	if err != nil {
+		return false, err
+	}
+	if _, err := s.Import(transformed, base); err != nil {
+		return false, err
+	}
+	if writeBack() {
+		changed = true
+	}
+	return changed, nil
+}
+
+// ImportTemplate creates the tickets of the ticket tree template rendered with the variables.
+func (s *Sync) ImportTemplate(file string, vars map[string]string) (*Config, error) {
+	config, err := s.Converter.ReadConfigTemplate(file, vars)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.Import(config, &Config{}); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
 func (s *Sync) createTicket(ticket *Ticket) (bool, error) {
 	var issue *redmine.Issue
 	changed := false
@@ -162,6 +244,9 @@ func (s *Sync) createTicket(ticket *Ticket) (bool, error) {
 		issue = created
 		// set created ticket ID in the input config file
 		ticket.ID = issue.Id
+		for _, child := range ticket.Children {
+			child.ParentID = ticket.ID
+		}
 		changed = true
 	} else {
 		// update
@@ -0,0 +1,286 @@
+package sync
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+var (
+	// templateLiteralPattern matches the values written as is into the ticket tree templates.
+	templateLiteralPattern = regexp.MustCompile(`^\w[\w./+-]*( [\w./+-]+)*$`)
+	// templateKeywordPattern matches the values read as null or booleans in YAML, which are not written as is.
+	templateKeywordPattern = regexp.MustCompile(`^(?i:null|true|false|yes|no|on|off|y|n)$`)
+)
+
+// SaveConfigTemplate executes the text/template file with the config.
+//
+// The template gets the config as the data, and the helper functions:
+//
+//	walk .|$project|$ticket    tickets in depth-first order, including the ticket itself
+//	depth $ticket              0 for the top level tickets
+//	path $ticket               ancestors from the top level ticket to the ticket
+//	parent $ticket             parent ticket or nil
+//	projectName $project.ID    project name of the ID
+//	isClosed $ticket.Status    whether the status is closed
+//	issueURL $ticket.ID        URL of the issue page
+//	today                      today as YYYY-MM-DD
+//	addDays "2018-10-20" 3     date after the days
+//	daysBetween $from $to      days from the date to the date
+//	formatDate "Jan 2" $date   date in the layout of the time package
+//	str $ticket.Subject        string of the pointer, empty for nil
+//	num $ticket.DoneRatio      int of the pointer, 0 for nil
+//	repeat, join, upper, lower, trim, replace
+func (c *Converter) SaveConfigTemplate(writer io.Writer, config *Config, file string) error {
+	if _, err := c.toFlat(config); err != nil {
+		return err
+	}
+	parents := map[*Ticket]*Ticket{}
+	var index func(parent *Ticket, tickets []*Ticket)
+	index = func(parent *Ticket, tickets []*Ticket) {
+		for _, t := range tickets {
+			parents[t] = parent
+			index(t, t.Children)
+		}
+	}
+	for _, p := range config.Projects {
+		index(nil, p.Tickets)
+	}
+
+	funcs := template.FuncMap{
+		"walk": func(v interface{}) ([]*Ticket, error) {
+			tickets := []*Ticket{}
+			var walk func(t *Ticket)
+			walk = func(t *Ticket) {
+				tickets = append(tickets, t)
+				for _, child := range t.Children {
+					walk(child)
+				}
+			}
+			switch v := v.(type) {
+			case *Config:
+				for _, p := range v.Projects {
+					for _, t := range p.Tickets {
+						walk(t)
+					}
+				}
+			case *Project:
+				for _, t := range v.Tickets {
+					walk(t)
+				}
+			case *Ticket:
+				walk(v)
+			case []*Ticket:
+				for _, t := range v {
+					walk(t)
+				}
+			default:
+				return nil, fmt.Errorf("walk: unsupported type: %T", v)
+			}
+			return tickets, nil
+		},
+		"depth": func(t *Ticket) int {
+			depth := 0
+			for p := parents[t]; p != nil; p = parents[p] {
+				depth++
+			}
+			return depth
+		},
+		"path": func(t *Ticket) []*Ticket {
+			path := []*Ticket{t}
+			for p := parents[t]; p != nil; p = parents[p] {
+				path = append([]*Ticket{p}, path...)
+			}
+			return path
+		},
+		"parent": func(t *Ticket) *Ticket {
+			return parents[t]
+		},
+		"projectName": c.Projects.FindNameByID,
+		"isClosed":    c.isClosed,
+		"issueURL":    c.IssueURL,
+		"str": func(s *string) string {
+			if s == nil {
+				return ""
+			}
+			return *s
+		},
+		"num": func(i *int) int {
+			if i == nil {
+				return 0
+			}
+			return *i
+		},
+		"repeat":  strings.Repeat,
+		"join":    strings.Join,
+		"upper":   strings.ToUpper,
+		"lower":   strings.ToLower,
+		"trim":    strings.TrimSpace,
+		"replace": strings.Replace,
+	}
+	for name, f := range dateFuncs {
+		funcs[name] = f
+	}
+	tmpl, err := template.New(filepath.Base(file)).Funcs(funcs).ParseFiles(file)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(writer, config)
+}
+
+// ReadConfigTemplate renders the ticket tree template with the variables, and reads the config of the new tickets.
+// The template is a config file, in the format of its extension, with the text/template placeholders such as
+// {{.version}}.
+// The functions of the date math and `projectID "name"` are available.
+//
+// The output of the placeholders is read as is, even if it has the characters of the format such as `: `, `#`,
+// the quotes and the newlines in YAML. The values except the simple words are written as the tokens, which are
+// replaced with the values in the strings of the read config. The words such as null and true are also written as
+// the tokens not to be read as null or booleans.
+func (c *Converter) ReadConfigTemplate(file string, vars map[string]string) (*Config, error) {
+	values := []string{}
+	funcs := template.FuncMap{
+		"projectID": c.Projects.FindIDByName,
+		"literal": func(v interface{}) string {
+			s := fmt.Sprint(v)
+			if s == "" || (templateLiteralPattern.MatchString(s) && !templateKeywordPattern.MatchString(s)) {
+				return s
+			}
+			values = append(values, s)
+			return fmt.Sprintf("redmine_sync_value_%d_", len(values)-1)
+		},
+	}
+	for name, f := range dateFuncs {
+		funcs[name] = f
+	}
+	tmpl, err := template.New(filepath.Base(file)).Funcs(funcs).Option("missingkey=error").ParseFiles(file)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			literalActions(t.Tree, t.Tree.Root)
+		}
+	}
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, vars); err != nil {
+		return nil, err
+	}
+	format, err := FormatOfFile(file)
+	if err != nil {
+		return nil, err
+	}
+	config, err := format.Read(c, &rendered)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", file, err)
+	}
+	tickets, err := c.toFlat(config)
+	if err != nil {
+		return nil, err
+	}
+	tokens := []string{}
+	for i, v := range values {
+		tokens = append(tokens, fmt.Sprintf("redmine_sync_value_%d_", i), v)
+	}
+	replacer := strings.NewReplacer(tokens...)
+	for _, t := range tickets {
+		if t.ID != 0 {
+			return nil, fmt.Errorf("%s: template must not have ticket IDs: %d", file, t.ID)
+		}
+		for _, field := range []*string{t.Project, t.Subject, t.Assignee, t.Status, t.Description, t.Tracker, t.StartDate, t.DueDate, t.Priority} {
+			if field != nil {
+				*field = replacer.Replace(*field)
+			}
+		}
+	}
+	return config, nil
+}
+
+// literalActions pipes the output of the actions in the node to the literal function.
+func literalActions(tree *parse.Tree, node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			literalActions(tree, child)
+		}
+	case *parse.ActionNode:
+		// the declarations don't write anything
+		if len(n.Pipe.Decl) == 0 {
+			literal := parse.NewIdentifier("literal").SetTree(tree).SetPos(n.Pos)
+			n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{NodeType: parse.NodeCommand, Pos: n.Pos, Args: []parse.Node{literal}})
+		}
+	case *parse.IfNode:
+		literalActions(tree, n.List)
+		literalActions(tree, n.ElseList)
+	case *parse.RangeNode:
+		literalActions(tree, n.List)
+		literalActions(tree, n.ElseList)
+	case *parse.WithNode:
+		literalActions(tree, n.List)
+		literalActions(tree, n.ElseList)
+	}
+}
+
+// dateFuncs are the template functions of the date math.
+var dateFuncs = template.FuncMap{
+	"today": func() string {
+		return time.Now().Format("2006-01-02")
+	},
+	"addDays": func(date interface{}, days int) (string, error) {
+		t, err := templateDate(date)
+		if err != nil || t.IsZero() {
+			return "", err
+		}
+		return t.AddDate(0, 0, days).Format("2006-01-02"), nil
+	},
+	"daysBetween": func(from interface{}, to interface{}) (int, error) {
+		f, err := templateDate(from)
+		if err != nil {
+			return 0, err
+		}
+		t, err := templateDate(to)
+		if err != nil {
+			return 0, err
+		}
+		if f.IsZero() || t.IsZero() {
+			return 0, nil
+		}
+		return int(t.Sub(f).Hours() / 24), nil
+	},
+	"formatDate": func(layout string, date interface{}) (string, error) {
+		t, err := templateDate(date)
+		if err != nil || t.IsZero() {
+			return "", err
+		}
+		return t.Format(layout), nil
+	},
+}
+
+// templateDate parses the date of YYYY-MM-DD given as string or *string.
+// The empty date is the zero time.
+func templateDate(date interface{}) (time.Time, error) {
+	var s string
+	switch d := date.(type) {
+	case string:
+		s = d
+	case *string:
+		if d != nil {
+			s = *d
+		}
+	default:
+		return time.Time{}, fmt.Errorf("unsupported date: %v", date)
+	}
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
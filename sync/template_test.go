@@ -0,0 +1,63 @@
+package sync
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadConfigTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		template string
+		vars     map[string]string
+		want     *Config
+	}{
+		{
+			"yaml",
+			"t.yml",
+			"projects:\n- id: {{projectID \"aaaa\"}}\n  tickets:\n  - subject: Release {{.version}}\n    due_date: {{.due}}\n    children:\n    - subject: \"Freeze {{.version}}\"\n      due_date: {{addDays .due -7}}\n      description: |\n        {{.notes}}\n",
+			map[string]string{"version": "2.3: final #1 'rc'", "due": "2024-06-30", "notes": "a\nb: c"},
+			&Config{Projects: []*Project{{ID: 1, Tickets: []*Ticket{
+				{Subject: stringPtr("Release 2.3: final #1 'rc'"), DueDate: stringPtr("2024-06-30"), Children: []*Ticket{
+					{Subject: stringPtr("Freeze 2.3: final #1 'rc'"), DueDate: stringPtr("2024-06-23"), Description: stringPtr("a\nb: c\n")},
+				}},
+			}}}},
+		},
+		{
+			"yaml keywords",
+			"t.yml",
+			"projects:\n- id: {{.project}}\n  tickets:\n  - subject: {{.subject}}\n    description: {{.description}}\n    status: {{.status}}\n    tracker: \"{{.tracker}}\"\n    done_ratio: {{.ratio}}\n    children:\n    - subject: {{.subject}} {{.description}}\n",
+			map[string]string{"project": "1", "subject": "null", "description": "True", "status": "~", "tracker": "NULL", "ratio": "30"},
+			&Config{Projects: []*Project{{ID: 1, Tickets: []*Ticket{
+				{Subject: stringPtr("null"), Description: stringPtr("True"), Status: stringPtr("~"), Tracker: stringPtr("NULL"), DoneRatio: intPtr(30), Children: []*Ticket{
+					{Subject: stringPtr("null True")},
+				}},
+			}}}},
+		},
+		{
+			"csv",
+			"t.csv",
+			"Project,ID,Parent ID,Subject\n{{.project}},0,0,{{.subject}}\n",
+			map[string]string{"project": "aaaa", "subject": "a, \"b\""},
+			&Config{Projects: []*Project{{ID: 1, Tickets: []*Ticket{
+				{Subject: stringPtr("a, \"b\"")},
+			}}}},
+		},
+	}
+	for _, test := range tests {
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+		file := filepath.Join(dir, test.file)
+		if err := ioutil.WriteFile(file, []byte(test.template), 0644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := testConverter().ReadConfigTemplate(file, test.vars)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		assertConfig(t, test.name, test.want, got)
+	}
+}
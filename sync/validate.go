@@ -0,0 +1,446 @@
+package sync
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	redmine "github.com/uphy/go-redmine"
+	yaml3 "gopkg.in/yaml.v3"
+)
+
+// Problem is a problem of the config file found by the validation.
+type Problem struct {
+	File string
+	// Line is the line of the problem, or 0 if unknown.
+	Line    int
+	Ticket  *Ticket
+	Message string
+}
+
+func (p Problem) String() string {
+	location := p.File
+	if p.Line > 0 {
+		location += ":" + strconv.Itoa(p.Line)
+	}
+	if p.Ticket == nil {
+		return location + ": " + p.Message
+	}
+	subject := ""
+	if p.Ticket.Subject != nil {
+		subject = " " + *p.Ticket.Subject
+	}
+	return fmt.Sprintf("%s: #%d%s: %s", location, p.Ticket.ID, subject, p.Message)
+}
+
+// Validate checks the config file, and returns all of the problems.
+//
+// The offline checks are the dates and the date expressions, the start date after the due date, the done ratio
+// which is not a multiple of 10 in 0 to 100, the duplicate IDs and the parent cycles.
+// online also checks the names of the projects, the statuses, the trackers, the priorities and the assignees,
+// the trackers not enabled in the projects and the assignees who are not the members of the projects.
+// The lines are reported for the YAML and CSV files and the directories.
+// The offline validation doesn't access the server, and only the statuses of the options are known.
+func (s *Sync) Validate(file string, online bool) ([]Problem, error) {
+	// the file is read with the placeholders of the unknown projects to check the rest of the file
+	c := *s.Converter
+	c.Projects = &Names{init: func() ([]redmine.IdName, error) {
+		if !online {
+			return []redmine.IdName{}, nil
+		}
+		if err := s.Converter.Projects.initIfNeeded(); err != nil {
+			return nil, err
+		}
+		return append([]redmine.IdName{}, s.Converter.Projects.names...), nil
+	}, placeholder: true}
+	if !online {
+		c.Statuses, c.ClosedStatuses = s.offlineStatuses()
+	}
+	// the parent cycles are reported after the file is read
+	type parentCycle struct {
+		ticket *Ticket
+		cycle  string
+	}
+	cycles := []parentCycle{}
+	c.parentCycle = func(t *Ticket, cycle string) {
+		cycles = append(cycles, parentCycle{t, cycle})
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	config, err := c.ReadConfig(f)
+	if err != nil {
+		// the file can't be checked further
+		return []Problem{{File: file, Message: err.Error()}}, nil
+	}
+	locate, err := c.ticketLocator(file, config)
+	if err != nil {
+		return nil, err
+	}
+
+	problems := []Problem{}
+	report := func(t *Ticket, field string, format string, args ...interface{}) {
+		name, line := locate(t, field)
+		if name == "" {
+			name = file
+		}
+		problems = append(problems, Problem{name, line, t, fmt.Sprintf(format, args...)})
+	}
+	for _, p := range cycles {
+		report(p.ticket, "parent", "parent cycle: %s", p.cycle)
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	ids := map[int]bool{}
+	resolved := map[*Ticket]map[string]time.Time{}
+	var check func(parent *Ticket, t *Ticket, ancestors map[int]bool, project int) error
+	check = func(parent *Ticket, t *Ticket, ancestors map[int]bool, project int) error {
+		dates := map[string]time.Time{}
+		resolved[t] = dates
+		raw := map[string]*string{"start_date": t.StartDate, "due_date": t.DueDate}
+		failed := map[string]bool{}
+		ref := func(name string) (time.Time, error) {
+			target, own := dates, true
+			if strings.HasPrefix(name, "parent.") {
+				if parent == nil {
+					return time.Time{}, fmt.Errorf("no parent to refer: %s", name)
+				}
+				target, own = resolved[parent], false
+				name = strings.TrimPrefix(name, "parent.")
+			}
+			key := map[string]string{"start": "start_date", "start_date": "start_date", "due": "due_date", "due_date": "due_date"}[name]
+			date, ok := target[key]
+			if !ok {
+				if own && raw[key] != nil && *raw[key] != "" {
+					if failed[key] {
+						return time.Time{}, fmt.Errorf("invalid date to refer: %s", name)
+					}
+					return time.Time{}, errUnresolvedDate
+				}
+				return time.Time{}, fmt.Errorf("no date to refer: %s", name)
+			}
+			return date, nil
+		}
+		// the expressions are resolved after the dates they refer to in as many passes as needed
+		pending := []string{}
+		for _, key := range []string{"start_date", "due_date"} {
+			if raw[key] != nil && *raw[key] != "" {
+				pending = append(pending, key)
+			}
+		}
+		for len(pending) > 0 {
+			rest := []string{}
+			for _, key := range pending {
+				date, err := c.resolveDate(*raw[key], today, ref)
+				if err == errUnresolvedDate {
+					rest = append(rest, key)
+					continue
+				}
+				if err != nil {
+					failed[key] = true
+					report(t, key, "invalid %s %q: %s", key, *raw[key], err)
+					continue
+				}
+				dates[key], _ = time.Parse(dateLayout, date)
+			}
+			if len(rest) == len(pending) {
+				for _, key := range rest {
+					report(t, key, "invalid %s %q: circular reference of the dates", key, *raw[key])
+				}
+				break
+			}
+			pending = rest
+		}
+		if start, ok := dates["start_date"]; ok {
+			if due, ok := dates["due_date"]; ok && start.After(due) {
+				report(t, "start_date", "start_date %s is after due_date %s", start.Format(dateLayout), due.Format(dateLayout))
+			}
+		}
+		if t.DoneRatio != nil && (*t.DoneRatio < 0 || *t.DoneRatio > 100 || *t.DoneRatio%10 != 0) {
+			report(t, "done_ratio", "done_ratio must be a multiple of 10 from 0 to 100: %d", *t.DoneRatio)
+		}
+		if t.ID != 0 {
+			if ancestors[t.ID] {
+				report(t, "id", "parent cycle: #%d is its own ancestor", t.ID)
+			} else if ids[t.ID] {
+				report(t, "id", "duplicate ID: %d", t.ID)
+			}
+			ids[t.ID] = true
+		}
+		if project != 0 {
+			if err := s.validateNames(t, project, report); err != nil {
+				return err
+			}
+		}
+
+		children := map[int]bool{t.ID: true}
+		for id := range ancestors {
+			children[id] = true
+		}
+		for _, child := range t.Children {
+			if err := check(t, child, children, project); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, p := range config.Projects {
+		project := p.ID
+		if online {
+			if _, err := s.Converter.Projects.FindNameByID(p.ID); err != nil {
+				name, line := locate(nil, strconv.Itoa(p.ID))
+				if name == "" {
+					name = file
+				}
+				// the negative ID is the placeholder of the unknown name
+				projectName, _ := c.Projects.FindNameByID(p.ID)
+				problems = append(problems, Problem{name, line, nil, "unknown project: " + projectName})
+				project = 0
+			}
+		} else {
+			project = 0
+		}
+		for _, t := range p.Tickets {
+			if err := check(nil, t, map[int]bool{}, project); err != nil {
+				return nil, err
+			}
+		}
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].File != problems[j].File {
+			return problems[i].File < problems[j].File
+		}
+		return problems[i].Line < problems[j].Line
+	})
+	return problems, nil
+}
+
+// offlineStatuses returns the statuses known without the server, which are the statuses of the options.
+// The other statuses are the placeholders.
+func (s *Sync) offlineStatuses() (statuses *Names, closedStatuses *Names) {
+	none := func() ([]redmine.IdName, error) {
+		return []redmine.IdName{}, nil
+	}
+	statuses = &Names{init: none, placeholder: true}
+	closedStatuses = &Names{init: none}
+	if name := s.Converter.ClosedStatus; name != "" {
+		statuses.names = append(statuses.names, redmine.IdName{Id: -1, Name: name})
+		closedStatuses.names = append(closedStatuses.names, redmine.IdName{Id: -1, Name: name})
+	}
+	if name := s.Converter.OpenStatus; name != "" {
+		statuses.names = append(statuses.names, redmine.IdName{Id: -2, Name: name})
+	}
+	return statuses, closedStatuses
+}
+
+// validateNames checks the names of the ticket in the project on the server.
+func (s *Sync) validateNames(t *Ticket, project int, report func(t *Ticket, field string, format string, args ...interface{})) error {
+	c := s.Converter
+	for _, field := range []struct {
+		key   string
+		value *string
+		names *Names
+	}{
+		{"status", t.Status, c.Statuses},
+		{"tracker", t.Tracker, c.Trackers},
+		{"priority", t.Priority, c.Priorities},
+		{"assignee", t.Assignee, c.Users},
+	} {
+		if field.value == nil || *field.value == "" {
+			continue
+		}
+		id, err := field.names.FindIDByName(*field.value)
+		if err != nil {
+			report(t, field.key, "unknown %s: %s", field.key, *field.value)
+			continue
+		}
+		switch field.key {
+		case "tracker":
+			trackers, err := s.projectTrackers(project)
+			if err != nil {
+				return err
+			}
+			if !trackers[id] {
+				report(t, field.key, "tracker is not enabled in the project: %s", *field.value)
+			}
+		case "assignee":
+			members, err := s.projectMembers(project)
+			if err != nil {
+				return err
+			}
+			if !members[id] {
+				report(t, field.key, "assignee is not a member of the project: %s", *field.value)
+			}
+		}
+	}
+	return nil
+}
+
+// projectTrackers returns the IDs of the trackers enabled in the project.
+func (s *Sync) projectTrackers(project int) (map[int]bool, error) {
+	if trackers, ok := s.trackers[project]; ok {
+		return trackers, nil
+	}
+	var r struct {
+		Project struct {
+			Trackers []struct {
+				ID int `json:"id"`
+			} `json:"trackers"`
+		} `json:"project"`
+	}
+	if err := s.get("/projects/"+strconv.Itoa(project)+".json?include=trackers", &r); err != nil {
+		return nil, err
+	}
+	trackers := map[int]bool{}
+	for _, t := range r.Project.Trackers {
+		trackers[t.ID] = true
+	}
+	if s.trackers == nil {
+		s.trackers = map[int]map[int]bool{}
+	}
+	s.trackers[project] = trackers
+	return trackers, nil
+}
+
+// projectMembers returns the IDs of the users who are the members of the project.
+func (s *Sync) projectMembers(project int) (map[int]bool, error) {
+	if members, ok := s.members[project]; ok {
+		return members, nil
+	}
+	members := map[int]bool{}
+	for offset := 0; ; {
+		var r struct {
+			Memberships []struct {
+				User *struct {
+					ID int `json:"id"`
+				} `json:"user"`
+			} `json:"memberships"`
+			TotalCount int `json:"total_count"`
+		}
+		if err := s.get("/projects/"+strconv.Itoa(project)+"/memberships.json?limit=100&offset="+strconv.Itoa(offset), &r); err != nil {
+			return nil, err
+		}
+		for _, m := range r.Memberships {
+			if m.User != nil {
+				members[m.User.ID] = true
+			}
+		}
+		offset += len(r.Memberships)
+		if len(r.Memberships) == 0 || offset >= r.TotalCount {
+			break
+		}
+	}
+	if s.members == nil {
+		s.members = map[int]map[int]bool{}
+	}
+	s.members[project] = members
+	return members, nil
+}
+
+// ticketLocator returns the function which locates the field of the ticket in the file.
+// The field of the nil ticket is the project ID.
+// It returns the empty file name and 0 if the location is unknown.
+func (c *Converter) ticketLocator(file string, config *Config) (func(t *Ticket, field string) (string, int), error) {
+	unknown := func(t *Ticket, field string) (string, int) {
+		return "", 0
+	}
+	if isDir(file) {
+		return func(t *Ticket, field string) (string, int) {
+			if t == nil || t.path == "" {
+				return "", 0
+			}
+			return t.path, frontMatterLine(t.path, field)
+		}, nil
+	}
+	if format, err := FormatOfFile(file); err == nil && (format.Name() == "csv" || format.Name() == "tsv") {
+		return func(t *Ticket, field string) (string, int) {
+			if t == nil {
+				return file, 0
+			}
+			return file, t.line
+		}, nil
+	}
+	if !c.isYAMLFile(file) {
+		return unknown, nil
+	}
+
+	src, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml3.Node
+	if err := unmarshalYAMLNode(src, &doc); err != nil || doc.Kind != yaml3.DocumentNode || len(doc.Content) != 1 {
+		return unknown, nil
+	}
+	nodes := map[*Ticket]*yaml3.Node{}
+	projects := map[string]*yaml3.Node{}
+	var index func(node *yaml3.Node, tickets []*Ticket)
+	index = func(node *yaml3.Node, tickets []*Ticket) {
+		if node == nil || node.Kind != yaml3.SequenceNode || len(node.Content) != len(tickets) {
+			return
+		}
+		for i, t := range tickets {
+			if n := node.Content[i]; n.Kind == yaml3.MappingNode {
+				nodes[t] = n
+				index(yamlValue(n, "children"), t.Children)
+			}
+		}
+	}
+	if list := yamlValue(doc.Content[0], "projects"); list != nil && list.Kind == yaml3.SequenceNode && len(list.Content) == len(config.Projects) {
+		for i, p := range config.Projects {
+			n := list.Content[i]
+			if n.Kind != yaml3.MappingNode {
+				continue
+			}
+			if id := yamlValue(n, "id"); id != nil {
+				projects[strconv.Itoa(p.ID)] = id
+			}
+			index(yamlValue(n, "tickets"), p.Tickets)
+		}
+	}
+	return func(t *Ticket, field string) (string, int) {
+		if t == nil {
+			if n, ok := projects[field]; ok {
+				return file, n.Line
+			}
+			return file, 0
+		}
+		n, ok := nodes[t]
+		if !ok {
+			return file, 0
+		}
+		if v := yamlValue(n, field); v != nil {
+			return file, v.Line
+		}
+		return file, n.Line
+	}, nil
+}
+
+// frontMatterLine returns the line of the key in the front matter of the ticket file, or 1 if not found.
+func frontMatterLine(file string, key string) int {
+	f, err := os.Open(file)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if n > 1 && line == "---" {
+			break
+		}
+		if strings.HasPrefix(line, key+":") {
+			return n
+		}
+	}
+	return 1
+}
@@ -0,0 +1,113 @@
+package sync
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateOffline(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		file     string
+		problems []string
+	}{
+		{
+			"csv parent cycle",
+			map[string]string{"a.csv": "Project,ID,Parent ID,Subject\naaaa,1,0,root\naaaa,2,3,\"two\nlines\"\naaaa,3,2,c\n"},
+			"a.csv",
+			[]string{"a.csv:3: #2 two\nlines: parent cycle: #2 -> #3 -> #2"},
+		},
+		{
+			"csv done ratio",
+			map[string]string{"a.csv": "Project,ID,Parent ID,Subject,Done Ratio\naaaa,1,0,a,0\naaaa,2,1,b,15\n"},
+			"a.csv",
+			[]string{"a.csv:3: #2 b: done_ratio must be a multiple of 10 from 0 to 100: 15"},
+		},
+		{
+			"dir parent cycle",
+			map[string]string{
+				"d/aaaa/5-a.md": "---\nsubject: a\nparent: 6\n---\n",
+				"d/aaaa/6-b.md": "---\nsubject: b\nparent: 5\n---\n",
+			},
+			"d",
+			[]string{"d/aaaa/5-a.md:3: #5 a: parent cycle: #5 -> #6 -> #5"},
+		},
+		{
+			"yaml date references",
+			map[string]string{"a.yml": `projects:
+- id: 1
+  tickets:
+  - id: 1
+    subject: a
+    start_date: due - 3d
+    due_date: 2024-06-10
+  - id: 2
+    subject: b
+    start_date: due
+    due_date: start
+  - id: 3
+    subject: c
+    start_date: due
+    due_date: someday
+`},
+			"a.yml",
+			[]string{
+				`a.yml:10: #2 b: invalid start_date "due": circular reference of the dates`,
+				`a.yml:11: #2 b: invalid due_date "start": circular reference of the dates`,
+				`a.yml:14: #3 c: invalid start_date "due": invalid date to refer: due`,
+				`a.yml:15: #3 c: invalid due_date "someday": unsupported date: someday`,
+			},
+		},
+		{
+			"markdown statuses",
+			map[string]string{"a.md": "# aaaa\n\n- [ ] #5 a (status: Closed)\n- [x] #6 b\n"},
+			"a.md",
+			[]string{},
+		},
+		{
+			"org statuses",
+			map[string]string{"a.org": "#+TODO: NEW | CLOSED\n* aaaa\n** NEW a\n"},
+			"a.org",
+			[]string{},
+		},
+	}
+	for _, test := range tests {
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+		for name, content := range test.files {
+			path := filepath.Join(dir, filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		c := testConverter()
+		// the server must not be accessed
+		c.Statuses.names, c.ClosedStatuses.names = nil, nil
+		s := &Sync{Converter: c}
+		problems, err := s.Validate(filepath.Join(dir, test.file), false)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		got := []string{}
+		for _, p := range problems {
+			rel, _ := filepath.Rel(dir, p.File)
+			p.File = filepath.ToSlash(rel)
+			got = append(got, p.String())
+		}
+		if len(got) != len(test.problems) {
+			t.Errorf("%s: want %q, got %q", test.name, test.problems, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.problems[i] {
+				t.Errorf("%s: want %q, got %q", test.name, test.problems[i], got[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package sync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+	redmine "github.com/uphy/go-redmine"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const wikiManifestFile = ".redmine-wiki.yml"
+
+type (
+	// WikiManifest records the project and the page versions of an exported wiki directory.
+	// Local changes are detected against the hashes of the texts of the recorded versions, so only the changed
+	// pages are sent to the server.
+	WikiManifest struct {
+		Project   int         `yaml:"project"`
+		Extension string      `yaml:"extension"`
+		Pages     []*WikiPage `yaml:"pages"`
+	}
+
+	WikiPage struct {
+		Title   string `yaml:"title"`
+		Parent  string `yaml:"parent,omitempty"`
+		Version int    `yaml:"version"`
+		// Hash is the SHA-256 of the text of the version with the LF line endings.
+		Hash string `yaml:"hash,omitempty"`
+		Text string `yaml:"-"`
+	}
+
+	WikiPageChange struct {
+		Page1  *WikiPage
+		Page2  *WikiPage
+		Change Change
+	}
+)
+
+func DiffWikiPages(pages1 []*WikiPage, pages2 []*WikiPage) []WikiPageChange {
+	pageMap := func(pages []*WikiPage) map[string]*WikiPage {
+		m := map[string]*WikiPage{}
+		for _, p := range pages {
+			m[p.Title] = p
+		}
+		return m
+	}
+	m1 := pageMap(pages1)
+	m2 := pageMap(pages2)
+
+	changes := []WikiPageChange{}
+	for _, p1 := range pages1 {
+		p2, ok := m2[p1.Title]
+		if ok {
+			if p1.textHash() != p2.textHash() || p1.Parent != p2.Parent {
+				changes = append(changes, WikiPageChange{p1, p2, ChangeUpdated})
+			}
+		} else {
+			changes = append(changes, WikiPageChange{p1, nil, ChangeRemoved})
+		}
+	}
+	for _, p2 := range pages2 {
+		if _, ok := m1[p2.Title]; !ok {
+			changes = append(changes, WikiPageChange{nil, p2, ChangeAdded})
+		}
+	}
+	return changes
+}
+
+func (s *Sync) ExportWiki(projectID int, dir string, extension string) error {
+	list, err := s.client.WikiPages(projectID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	manifest := &WikiManifest{
+		Project:   projectID,
+		Extension: extension,
+	}
+	for _, item := range list {
+		page, err := s.client.WikiPage(projectID, item.Title)
+		if err != nil {
+			return err
+		}
+		p := newWikiPage(page)
+		path := filepath.Join(dir, manifest.pagePath(p.Title, list))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path, []byte(p.Text), 0644); err != nil {
+			return err
+		}
+		manifest.Pages = append(manifest.Pages, p)
+	}
+	return saveWikiManifest(dir, manifest)
+}
+
+func (s *Sync) ImportWiki(dir string) error {
+	manifest, err := readWikiManifest(dir)
+	if err != nil {
+		return err
+	}
+	base := []*WikiPage{}
+	// legacy is whether the manifest was written before the hashes were recorded
+	legacy := false
+	for _, p := range manifest.Pages {
+		if p.Hash != "" {
+			base = append(base, p)
+			continue
+		}
+		legacy = true
+		page, err := s.client.WikiPageAtVersion(manifest.Project, p.Title, fmt.Sprint(p.Version))
+		if err != nil {
+			return err
+		}
+		b := newWikiPage(page)
+		b.Parent = p.Parent
+		base = append(base, b)
+	}
+	pages, err := manifest.readPages(dir)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for _, change := range DiffWikiPages(base, pages) {
+		switch change.Change {
+		case ChangeAdded, ChangeUpdated:
+			s.logger.Printf("Updating wiki page %s...", change.Page2.Title)
+			page := redmine.WikiPage{
+				Title: change.Page2.Title,
+				Text:  change.Page2.Text,
+			}
+			if change.Page2.Parent != "" {
+				page.Parent = &redmine.Parent{Title: change.Page2.Parent}
+			}
+			if change.Page1 != nil {
+				// let the server reject the update if the page was modified after the export
+				page.Version = change.Page1.Version
+			}
+			if err := s.client.UpdateWikiPage(manifest.Project, page); err != nil {
+				return err
+			}
+			updated, err := s.client.WikiPage(manifest.Project, page.Title)
+			if err != nil {
+				return err
+			}
+			change.Page2.Version = newWikiPage(updated).Version
+			changed = true
+		}
+	}
+	if changed || legacy {
+		manifest.Pages = pages
+		return saveWikiManifest(dir, manifest)
+	}
+	return nil
+}
+
+func (s *Sync) WatchWiki(dir string, ignoreImportError bool) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	if err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return w.Add(path)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for evt := range w.Events {
+		if evt.Op&(fsnotify.Write|fsnotify.Create) == 0 || filepath.Base(evt.Name) == wikiManifestFile {
+			continue
+		}
+		if info, err := os.Stat(evt.Name); err == nil && info.IsDir() {
+			if err := w.Add(evt.Name); err != nil {
+				return err
+			}
+			continue
+		}
+		s.logger.Println("Detected file modification.")
+		s.logger.Println("Importing the changes...")
+		if err := s.ImportWiki(dir); err != nil {
+			if ignoreImportError {
+				s.logger.Printf("Failed to import: %s", err)
+				continue
+			}
+			return err
+		}
+		s.logger.Println("Successfully applied the changes.")
+	}
+	return nil
+}
+
+func newWikiPage(page *redmine.WikiPage) *WikiPage {
+	p := &WikiPage{
+		Title: page.Title,
+		Text:  normalizeNewlines(page.Text),
+	}
+	p.Hash = p.textHash()
+	if page.Parent != nil {
+		p.Parent = page.Parent.Title
+	}
+	switch v := page.Version.(type) {
+	case float64:
+		p.Version = int(v)
+	case int:
+		p.Version = v
+	}
+	return p
+}
+
+// textHash returns the recorded hash of the page, or the hash of the text if it is not recorded.
+func (p *WikiPage) textHash() string {
+	if p.Hash != "" {
+		return p.Hash
+	}
+	sum := sha256.Sum256([]byte(p.Text))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeNewlines replaces the CRLF line endings with LF, which are written by the editors on Windows and
+// returned by Redmine for the pages edited in the browser.
+func normalizeNewlines(s string) string {
+	return strings.Replace(s, "\r\n", "\n", -1)
+}
+
+// pagePath returns the file path of the page relative to the wiki directory.
+// Parent pages become the subfolders.
+func (m *WikiManifest) pagePath(title string, list []redmine.WikiPage) string {
+	parents := map[string]string{}
+	for _, p := range list {
+		if p.Parent != nil {
+			parents[p.Title] = p.Parent.Title
+		}
+	}
+	path := title + m.Extension
+	for t, visited := title, map[string]bool{}; parents[t] != "" && !visited[t]; t = parents[t] {
+		visited[t] = true
+		path = filepath.Join(parents[t], path)
+	}
+	return path
+}
+
+func (m *WikiManifest) readPages(dir string) ([]*WikiPage, error) {
+	pages := []*WikiPage{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || strings.ToLower(filepath.Ext(path)) != m.Extension {
+			return nil
+		}
+		text, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		page := &WikiPage{
+			Title: strings.TrimSuffix(filepath.Base(rel), filepath.Ext(rel)),
+			Text:  normalizeNewlines(string(text)),
+		}
+		page.Hash = page.textHash()
+		if parent := filepath.Dir(rel); parent != "." {
+			page.Parent = filepath.Base(parent)
+		}
+		for _, p := range m.Pages {
+			if p.Title == page.Title {
+				page.Version = p.Version
+			}
+		}
+		pages = append(pages, page)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(pages, func(i, j int) bool {
+		return pages[i].Title < pages[j].Title
+	})
+	return pages, nil
+}
+
+func readWikiManifest(dir string) (*WikiManifest, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, wikiManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	var manifest WikiManifest
+	if err := yaml.Unmarshal(b, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+func saveWikiManifest(dir string, manifest *WikiManifest) error {
+	b, err := yaml.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, wikiManifestFile), b, 0644)
+}
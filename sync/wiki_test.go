@@ -0,0 +1,171 @@
+package sync
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	redmine "github.com/uphy/go-redmine"
+)
+
+func tempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "redmine-sync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// mustJSON returns the value as JSON for the failure messages.
+func mustJSON(t *testing.T, v interface{}) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestWikiPagePath(t *testing.T) {
+	list := []redmine.WikiPage{
+		{Title: "Wiki"},
+		{Title: "Guide", Parent: &redmine.Parent{Title: "Wiki"}},
+		{Title: "Install", Parent: &redmine.Parent{Title: "Guide"}},
+		{Title: "A", Parent: &redmine.Parent{Title: "B"}},
+		{Title: "B", Parent: &redmine.Parent{Title: "A"}},
+	}
+	m := &WikiManifest{Extension: ".textile"}
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Wiki", "Wiki.textile"},
+		{"Guide", "Wiki/Guide.textile"},
+		{"Install", "Wiki/Guide/Install.textile"},
+		// the parent cycle ends at the page visited again
+		{"A", "A/B/A.textile"},
+	}
+	for _, test := range tests {
+		if got := filepath.ToSlash(m.pagePath(test.title, list)); got != test.want {
+			t.Errorf("%s: want %s, got %s", test.title, test.want, got)
+		}
+	}
+}
+
+// writeWikiFiles writes the files of the slash separated paths to the directory.
+func writeWikiFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, text := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReadWikiPages(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	writeWikiFiles(t, dir, map[string]string{
+		"Wiki.textile":               "h1. Wiki\r\n\r\ntext\r\n",
+		"Wiki/Guide.textile":         "guide\n",
+		"Wiki/Guide/Install.TEXTILE": "install\n",
+		"Wiki/notes.txt":             "not a page\n",
+	})
+	m := &WikiManifest{Extension: ".textile", Pages: []*WikiPage{{Title: "Guide", Parent: "Wiki", Version: 3}}}
+	pages, err := m.readPages(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*WikiPage{
+		{Title: "Guide", Parent: "Wiki", Version: 3, Text: "guide\n"},
+		{Title: "Install", Parent: "Guide", Text: "install\n"},
+		{Title: "Wiki", Text: "h1. Wiki\n\ntext\n"},
+	}
+	for _, p := range want {
+		p.Hash = p.textHash()
+	}
+	if !reflect.DeepEqual(pages, want) {
+		t.Errorf("want %s, got %s", mustJSON(t, want), mustJSON(t, pages))
+	}
+}
+
+func TestImportWiki(t *testing.T) {
+	hash := func(text string) string {
+		return (&WikiPage{Text: text}).textHash()
+	}
+	versions := map[string]int{"Wiki": 2, "Guide": 5}
+	requests := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		title := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/projects/1/wiki/"), ".json")
+		switch r.Method {
+		case "PUT":
+			versions[title]++
+		case "GET":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"wiki_page": map[string]interface{}{"title": title, "text": "", "version": versions[title]},
+			})
+		}
+	}))
+	defer server.Close()
+	s, err := New(server.URL, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	manifest := &WikiManifest{Project: 1, Extension: ".textile", Pages: []*WikiPage{
+		{Title: "Wiki", Version: 2, Hash: hash("wiki\n")},
+		{Title: "Guide", Parent: "Wiki", Version: 5, Hash: hash("guide\n")},
+	}}
+	if err := saveWikiManifest(dir, manifest); err != nil {
+		t.Fatal(err)
+	}
+	writeWikiFiles(t, dir, map[string]string{
+		// the line endings are changed by an editor
+		"Wiki.textile":       "wiki\r\n",
+		"Wiki/Guide.textile": "guide\nedited\n",
+		"Wiki/New.textile":   "new\n",
+	})
+	if err := s.ImportWiki(dir); err != nil {
+		t.Fatal(err)
+	}
+	// only the changed pages are pushed and fetched for the new versions
+	want := []string{"PUT /projects/1/wiki/Guide.json", "GET /projects/1/wiki/Guide.json", "PUT /projects/1/wiki/New.json", "GET /projects/1/wiki/New.json"}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("want the requests %v, got %v", want, requests)
+	}
+	saved, err := readWikiManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantManifest := &WikiManifest{Project: 1, Extension: ".textile", Pages: []*WikiPage{
+		{Title: "Guide", Parent: "Wiki", Version: 6, Hash: hash("guide\nedited\n")},
+		{Title: "New", Parent: "Wiki", Version: 1, Hash: hash("new\n")},
+		{Title: "Wiki", Version: 2, Hash: hash("wiki\n")},
+	}}
+	if !reflect.DeepEqual(saved, wantManifest) {
+		t.Errorf("want the manifest %s, got %s", mustJSON(t, wantManifest), mustJSON(t, saved))
+	}
+
+	// nothing is pushed without the changes
+	requests = nil
+	if err := s.ImportWiki(dir); err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 0 {
+		t.Errorf("want no requests, got %v", requests)
+	}
+}
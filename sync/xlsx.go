@@ -0,0 +1,700 @@
+package sync
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// XLSX format.
+// Each project is written to its own sheet, and the hierarchy of the tickets is written as the row outline.
+// The names available for the status, tracker, priority and assignee are written to a hidden sheet
+// and used for the dropdowns of the cells.
+// The hidden sheet also has the project IDs of the sheets, because the sheet names can't be the project names with
+// some characters or more than 31 characters. The sheets not in the list are read as the projects of their names.
+
+const (
+	xlsxListsSheet = "_lists"
+	// xlsxMaxOutlineLevel is the maximum outline level supported by Excel.
+	xlsxMaxOutlineLevel = 7
+
+	xlsxStyleHeader = 1
+	xlsxStyleDate   = 2
+	// xlsxStyleIndent is the style of the subject at depth 0, followed by the deeper ones.
+	xlsxStyleIndent = 3
+	xlsxMaxIndent   = 15
+)
+
+var (
+	xlsxColumns      = []string{"ID", "Parent ID", "Subject", "Assignee", "Status", "Done Ratio", "Description", "Tracker", "Start Date", "Due Date", "Priority", "Duration"}
+	xlsxColumnWidths = []int{8, 10, 50, 20, 15, 10, 50, 15, 12, 12, 12, 10}
+	// xlsxListsColumns are the columns of the hidden sheet, the lists of the dropdowns first.
+	xlsxListsColumns = []string{"Status", "Tracker", "Priority", "Assignee", "Sheet", "Project ID"}
+	xlsxEpoch        = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+)
+
+type (
+	xlsxWorkbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	xlsxRelationships struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	xlsxSharedStrings struct {
+		Items []xlsxText `xml:"si"`
+	}
+	xlsxText struct {
+		T string `xml:"t"`
+		R []struct {
+			T string `xml:"t"`
+		} `xml:"r"`
+	}
+	xlsxWorksheet struct {
+		Rows []struct {
+			Number       int `xml:"r,attr"`
+			OutlineLevel int `xml:"outlineLevel,attr"`
+			Cells        []struct {
+				Ref    string   `xml:"r,attr"`
+				Type   string   `xml:"t,attr"`
+				Value  string   `xml:"v"`
+				Inline xlsxText `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+)
+
+func (t xlsxText) String() string {
+	s := t.T
+	for _, r := range t.R {
+		s += r.T
+	}
+	return s
+}
+
+func (c *Converter) SaveConfigXLSX(writer io.Writer, config *Config) error {
+	lists := [][]string{}
+	for _, names := range []*Names{c.Statuses, c.Trackers, c.Priorities, c.Users} {
+		l, err := names.list()
+		if err != nil {
+			return err
+		}
+		lists = append(lists, l)
+	}
+
+	sheetNames := []string{}
+	projectIDs := []string{}
+	sheets := [][]byte{}
+	for _, p := range config.Projects {
+		name, err := c.Projects.FindNameByID(p.ID)
+		if err != nil {
+			return err
+		}
+		sheet, err := xlsxProjectSheet(p, lists, len(sheets) == 0)
+		if err != nil {
+			return err
+		}
+		sheetNames = append(sheetNames, xlsxSheetName(name, sheetNames))
+		projectIDs = append(projectIDs, strconv.Itoa(p.ID))
+		sheets = append(sheets, sheet)
+	}
+	if len(sheets) == 0 {
+		sheet, err := xlsxProjectSheet(&Project{}, lists, true)
+		if err != nil {
+			return err
+		}
+		sheetNames = append(sheetNames, "Sheet1")
+		sheets = append(sheets, sheet)
+	}
+	lists = append(lists, append([]string{}, sheetNames[:len(projectIDs)]...), projectIDs)
+	sheetNames = append(sheetNames, xlsxListsSheet)
+	sheets = append(sheets, xlsxListsSheetXML(lists))
+
+	z := zip.NewWriter(writer)
+	files := []struct {
+		name    string
+		content []byte
+	}{
+		{"[Content_Types].xml", xlsxContentTypes(len(sheets))},
+		{"_rels/.rels", []byte(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`)},
+		{"xl/workbook.xml", xlsxWorkbookXML(sheetNames)},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels(len(sheets))},
+		{"xl/styles.xml", xlsxStyles()},
+	}
+	for i, sheet := range sheets {
+		files = append(files, struct {
+			name    string
+			content []byte
+		}{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), sheet})
+	}
+	for _, f := range files {
+		w, err := z.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(f.content); err != nil {
+			return err
+		}
+	}
+	return z.Close()
+}
+
+func xlsxProjectSheet(project *Project, lists [][]string, selected bool) ([]byte, error) {
+	var rows bytes.Buffer
+	rows.WriteString(`<row r="1">`)
+	for i, column := range xlsxColumns {
+		xlsxWriteString(&rows, i, 1, column, xlsxStyleHeader)
+	}
+	rows.WriteString(`</row>`)
+
+	rowNumber := 1
+	maxLevel := 0
+	var writeTicket func(t *Ticket, parentID int, depth int) error
+	writeTicket = func(t *Ticket, parentID int, depth int) error {
+		rowNumber++
+		level := depth
+		if level > xlsxMaxOutlineLevel {
+			level = xlsxMaxOutlineLevel
+		}
+		if level > maxLevel {
+			maxLevel = level
+		}
+		if level > 0 {
+			fmt.Fprintf(&rows, `<row r="%d" outlineLevel="%d">`, rowNumber, level)
+		} else {
+			fmt.Fprintf(&rows, `<row r="%d">`, rowNumber)
+		}
+		if t.ID != 0 {
+			xlsxWriteNumber(&rows, 0, rowNumber, float64(t.ID), 0)
+		}
+		if parentID != 0 {
+			xlsxWriteNumber(&rows, 1, rowNumber, float64(parentID), 0)
+		}
+		indent := depth
+		if indent > xlsxMaxIndent {
+			indent = xlsxMaxIndent
+		}
+		xlsxWriteStringPtr(&rows, 2, rowNumber, t.Subject, xlsxStyleIndent+indent)
+		xlsxWriteStringPtr(&rows, 3, rowNumber, t.Assignee, 0)
+		xlsxWriteStringPtr(&rows, 4, rowNumber, t.Status, 0)
+		if t.DoneRatio != nil {
+			xlsxWriteNumber(&rows, 5, rowNumber, float64(*t.DoneRatio), 0)
+		}
+		xlsxWriteStringPtr(&rows, 6, rowNumber, t.Description, 0)
+		xlsxWriteStringPtr(&rows, 7, rowNumber, t.Tracker, 0)
+		for i, date := range []*string{t.StartDate, t.DueDate} {
+			if date == nil || *date == "" {
+				continue
+			}
+			d, err := time.Parse("2006-01-02", *date)
+			if err != nil {
+				return err
+			}
+			xlsxWriteNumber(&rows, 8+i, rowNumber, d.Sub(xlsxEpoch).Hours()/24, xlsxStyleDate)
+		}
+		xlsxWriteStringPtr(&rows, 10, rowNumber, t.Priority, 0)
+		if t.Duration != nil {
+			xlsxWriteNumber(&rows, 11, rowNumber, float64(*t.Duration), 0)
+		}
+		rows.WriteString(`</row>`)
+		for _, child := range t.Children {
+			if err := writeTicket(child, t.ID, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, t := range project.Tickets {
+		if err := writeTicket(t, 0, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">`)
+	b.WriteString(`<sheetPr><outlinePr summaryBelow="0"/></sheetPr>`)
+	tabSelected := ""
+	if selected {
+		tabSelected = ` tabSelected="1"`
+	}
+	fmt.Fprintf(&b, `<sheetViews><sheetView workbookViewId="0"%s><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`, tabSelected)
+	fmt.Fprintf(&b, `<sheetFormatPr defaultRowHeight="15" outlineLevelRow="%d"/>`, maxLevel)
+	b.WriteString(`<cols>`)
+	for i, width := range xlsxColumnWidths {
+		fmt.Fprintf(&b, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, i+1, i+1, width)
+	}
+	b.WriteString(`</cols>`)
+	b.WriteString(`<sheetData>`)
+	b.Write(rows.Bytes())
+	b.WriteString(`</sheetData>`)
+	// dropdowns of status, tracker, priority and assignee
+	validations := []int{4, 7, 10, 3}
+	fmt.Fprintf(&b, `<dataValidations count="%d">`, len(validations))
+	for i, column := range validations {
+		last := len(lists[i]) + 1
+		if last < 2 {
+			last = 2
+		}
+		col := xlsxColumnName(column)
+		fmt.Fprintf(&b, `<dataValidation type="list" allowBlank="1" showErrorMessage="1" sqref="%s2:%s1048576"><formula1>'%s'!$%s$2:$%s$%d</formula1></dataValidation>`,
+			col, col, xlsxListsSheet, xlsxColumnName(i), xlsxColumnName(i), last)
+	}
+	b.WriteString(`</dataValidations>`)
+	b.WriteString(`</worksheet>`)
+	return b.Bytes(), nil
+}
+
+func xlsxListsSheetXML(lists [][]string) []byte {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for row := 0; ; row++ {
+		found := false
+		var cells bytes.Buffer
+		for col, list := range lists {
+			if row == 0 {
+				xlsxWriteString(&cells, col, 1, xlsxListsColumns[col], 0)
+				found = true
+			} else if row <= len(list) {
+				xlsxWriteString(&cells, col, row+1, list[row-1], 0)
+				found = true
+			}
+		}
+		if !found {
+			break
+		}
+		fmt.Fprintf(&b, `<row r="%d">`, row+1)
+		b.Write(cells.Bytes())
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.Bytes()
+}
+
+func xlsxWorkbookXML(sheetNames []string) []byte {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, name := range sheetNames {
+		state := ""
+		if name == xlsxListsSheet {
+			state = ` state="hidden"`
+		}
+		fmt.Fprintf(&b, `<sheet name="%s" sheetId="%d"%s r:id="rId%d"/>`, xlsxEscape(name), i+1, state, i+1)
+	}
+	b.WriteString(`</sheets></workbook>`)
+	return b.Bytes()
+}
+
+func xlsxWorkbookRels(sheets int) []byte {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := 0; i < sheets; i++ {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, sheets+1)
+	b.WriteString(`</Relationships>`)
+	return b.Bytes()
+}
+
+func xlsxContentTypes(sheets int) []byte {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	b.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := 0; i < sheets; i++ {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+	}
+	b.WriteString(`</Types>`)
+	return b.Bytes()
+}
+
+func xlsxStyles() []byte {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	b.WriteString(`<numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy\-mm\-dd"/></numFmts>`)
+	b.WriteString(`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>`)
+	b.WriteString(`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>`)
+	b.WriteString(`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>`)
+	b.WriteString(`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>`)
+	fmt.Fprintf(&b, `<cellXfs count="%d">`, xlsxStyleIndent+xlsxMaxIndent+1)
+	b.WriteString(`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>`)
+	b.WriteString(`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>`)
+	b.WriteString(`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>`)
+	for i := 0; i <= xlsxMaxIndent; i++ {
+		fmt.Fprintf(&b, `<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0" applyAlignment="1"><alignment indent="%d"/></xf>`, i)
+	}
+	b.WriteString(`</cellXfs>`)
+	b.WriteString(`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>`)
+	b.WriteString(`</styleSheet>`)
+	return b.Bytes()
+}
+
+func xlsxWriteString(b *bytes.Buffer, col int, row int, value string, style int) {
+	fmt.Fprintf(b, `<c r="%s%d" t="inlineStr"`, xlsxColumnName(col), row)
+	if style != 0 {
+		fmt.Fprintf(b, ` s="%d"`, style)
+	}
+	fmt.Fprintf(b, `><is><t xml:space="preserve">%s</t></is></c>`, xlsxEscape(value))
+}
+
+func xlsxWriteStringPtr(b *bytes.Buffer, col int, row int, value *string, style int) {
+	if value != nil {
+		xlsxWriteString(b, col, row, *value, style)
+	}
+}
+
+func xlsxWriteNumber(b *bytes.Buffer, col int, row int, value float64, style int) {
+	fmt.Fprintf(b, `<c r="%s%d"`, xlsxColumnName(col), row)
+	if style != 0 {
+		fmt.Fprintf(b, ` s="%d"`, style)
+	}
+	fmt.Fprintf(b, `><v>%s</v></c>`, strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+func xlsxEscape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// xlsxColumnName converts the zero-based column index to the column name such as "A" or "AB".
+func xlsxColumnName(col int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name
+}
+
+// xlsxColumnIndex returns the zero-based column index of the cell reference such as "AB12".
+func xlsxColumnIndex(ref string) int {
+	col := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A') + 1
+	}
+	return col - 1
+}
+
+// xlsxSheetName returns the name usable as a sheet name, which is limited to 31 characters.
+func xlsxSheetName(name string, used []string) string {
+	name = strings.NewReplacer("[", "(", "]", ")", ":", "_", "*", "_", "?", "_", "/", "_", "\\", "_").Replace(name)
+	r := []rune(name)
+	if len(r) > 31 {
+		r = r[:31]
+	}
+	candidate := string(r)
+	for i := 2; ; i++ {
+		found := false
+		for _, u := range used {
+			if strings.EqualFold(u, candidate) {
+				found = true
+			}
+		}
+		if !found {
+			return candidate
+		}
+		suffix := fmt.Sprintf(" (%d)", i)
+		if len(r)+len([]rune(suffix)) > 31 {
+			r = r[:31-len([]rune(suffix))]
+		}
+		candidate = string(r) + suffix
+	}
+}
+
+func (c *Converter) readConfigXLSX(reader io.Reader) (*Config, error) {
+	b, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	z, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]*zip.File{}
+	for _, f := range z.File {
+		files[f.Name] = f
+	}
+	readXML := func(name string, v interface{}) error {
+		f, ok := files[name]
+		if !ok {
+			return fmt.Errorf("no such entry in xlsx: %s", name)
+		}
+		r, err := f.Open()
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		return xml.NewDecoder(r).Decode(v)
+	}
+
+	var workbook xlsxWorkbook
+	if err := readXML("xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	var rels xlsxRelationships
+	if err := readXML("xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	var sharedStrings xlsxSharedStrings
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := readXML("xl/sharedStrings.xml", &sharedStrings); err != nil {
+			return nil, err
+		}
+	}
+
+	readSheet := func(id string) ([]xlsxRecord, error) {
+		target := ""
+		for _, rel := range rels.Relationships {
+			if rel.ID == id {
+				target = rel.Target
+			}
+		}
+		if strings.HasPrefix(target, "/") {
+			target = strings.TrimPrefix(target, "/")
+		} else {
+			target = path.Join("xl", target)
+		}
+		var worksheet xlsxWorksheet
+		if err := readXML(target, &worksheet); err != nil {
+			return nil, err
+		}
+		return xlsxRecords(&worksheet, &sharedStrings)
+	}
+
+	// the project IDs of the sheets
+	sheetProjects := map[string]int{}
+	for _, sheet := range workbook.Sheets {
+		if sheet.Name != xlsxListsSheet {
+			continue
+		}
+		records, err := readSheet(sheet.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range records {
+			if r.values["Sheet"] == "" {
+				continue
+			}
+			id, err := xlsxInt(r.values["Project ID"])
+			if err != nil {
+				return nil, fmt.Errorf("sheet %s: row %d: %s", xlsxListsSheet, r.row, err)
+			}
+			sheetProjects[r.values["Sheet"]] = id
+		}
+	}
+
+	config := &Config{}
+	for _, sheet := range workbook.Sheets {
+		if sheet.Name == xlsxListsSheet {
+			continue
+		}
+		records, err := readSheet(sheet.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			continue
+		}
+		projectID, ok := sheetProjects[sheet.Name]
+		if !ok {
+			if projectID, err = c.Projects.FindIDByName(sheet.Name); err != nil {
+				return nil, fmt.Errorf("sheet %s: %s", sheet.Name, err)
+			}
+		}
+		project := config.findOrCreateProject(projectID)
+		if err := readXLSXTickets(project, records); err != nil {
+			return nil, fmt.Errorf("sheet %s: %s", sheet.Name, err)
+		}
+	}
+	if _, err := c.toFlat(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// xlsxRecord is a row of the values by the column names of the header row.
+type xlsxRecord struct {
+	row          int
+	outlineLevel int
+	values       map[string]string
+}
+
+// xlsxRecords returns the rows of the sheet except the header row and the empty rows.
+func xlsxRecords(worksheet *xlsxWorksheet, sharedStrings *xlsxSharedStrings) ([]xlsxRecord, error) {
+	header := map[int]string{}
+	records := []xlsxRecord{}
+	for i, row := range worksheet.Rows {
+		values := map[string]string{}
+		for _, cell := range row.Cells {
+			var value string
+			switch cell.Type {
+			case "s":
+				index, err := strconv.Atoi(cell.Value)
+				if err != nil || index >= len(sharedStrings.Items) {
+					return nil, fmt.Errorf("invalid shared string: %s", cell.Value)
+				}
+				value = sharedStrings.Items[index].String()
+			case "inlineStr":
+				value = cell.Inline.String()
+			default:
+				value = cell.Value
+			}
+			if i == 0 {
+				header[xlsxColumnIndex(cell.Ref)] = value
+			} else if column, ok := header[xlsxColumnIndex(cell.Ref)]; ok {
+				values[column] = value
+			}
+		}
+		if i == 0 || len(values) == 0 {
+			continue
+		}
+		number := row.Number
+		if number == 0 {
+			number = i + 1
+		}
+		records = append(records, xlsxRecord{number, row.OutlineLevel, values})
+	}
+	return records, nil
+}
+
+// readXLSXTickets reads the tickets of the sheet into the project.
+// The parent of a ticket is given by the Parent ID column, or by the row outline for the new tickets without IDs.
+func readXLSXTickets(project *Project, records []xlsxRecord) error {
+	type entry struct {
+		ticket       *Ticket
+		row          int
+		parentID     int
+		outlineLevel int
+		outline      *Ticket
+	}
+	entries := []*entry{}
+	var stack []*entry
+	for _, r := range records {
+		t, parentID, err := readXLSXTicket(r.values)
+		if err != nil {
+			return fmt.Errorf("row %d: %s", r.row, err)
+		}
+		e := &entry{ticket: t, row: r.row, parentID: parentID, outlineLevel: r.outlineLevel}
+		for len(stack) > 0 && stack[len(stack)-1].outlineLevel >= e.outlineLevel {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) > 0 {
+			e.outline = stack[len(stack)-1].ticket
+		}
+		stack = append(stack, e)
+		entries = append(entries, e)
+	}
+
+	idToTickets := map[int]*Ticket{}
+	for _, e := range entries {
+		if e.ticket.ID != 0 {
+			idToTickets[e.ticket.ID] = e.ticket
+		}
+	}
+	for _, e := range entries {
+		parent := e.outline
+		if e.parentID != 0 {
+			p, ok := idToTickets[e.parentID]
+			if !ok {
+				// the parent of a ticket is written in the same sheet
+				return fmt.Errorf("row %d: no such parent in the sheet: %d", e.row, e.parentID)
+			}
+			parent = p
+		}
+		if parent == nil {
+			project.Tickets = append(project.Tickets, e.ticket)
+		} else {
+			parent.Children = append(parent.Children, e.ticket)
+		}
+	}
+	return nil
+}
+
+// readXLSXTicket reads the ticket and the parent ID of the values of a row.
+func readXLSXTicket(values map[string]string) (t *Ticket, parentID int, err error) {
+	t = &Ticket{}
+	if t.ID, err = xlsxInt(values["ID"]); err != nil {
+		return nil, 0, err
+	}
+	if parentID, err = xlsxInt(values["Parent ID"]); err != nil {
+		return nil, 0, err
+	}
+	stringValue := func(column string) *string {
+		if v, ok := values[column]; ok {
+			return &v
+		}
+		return nil
+	}
+	t.Subject = stringValue("Subject")
+	t.Assignee = stringValue("Assignee")
+	t.Status = stringValue("Status")
+	t.Description = stringValue("Description")
+	t.Tracker = stringValue("Tracker")
+	t.Priority = stringValue("Priority")
+	for _, field := range []struct {
+		column string
+		value  **int
+	}{{"Done Ratio", &t.DoneRatio}, {"Duration", &t.Duration}} {
+		if v, ok := values[field.column]; ok {
+			i, err := xlsxInt(v)
+			if err != nil {
+				return nil, 0, err
+			}
+			*field.value = &i
+		}
+	}
+	if t.StartDate, err = xlsxDate(values, "Start Date"); err != nil {
+		return nil, 0, err
+	}
+	if t.DueDate, err = xlsxDate(values, "Due Date"); err != nil {
+		return nil, 0, err
+	}
+	return t, parentID, nil
+}
+
+func xlsxInt(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number: %s", s)
+	}
+	return int(f), nil
+}
+
+// xlsxDate reads the date written as a serial number or as a string.
+func xlsxDate(values map[string]string, column string) (*string, error) {
+	v, ok := values[column]
+	if !ok {
+		return nil, nil
+	}
+	if serial, err := strconv.ParseFloat(v, 64); err == nil {
+		d := xlsxEpoch.Add(time.Duration(math.Floor(serial)) * 24 * time.Hour).Format("2006-01-02")
+		return &d, nil
+	}
+	return &v, nil
+}
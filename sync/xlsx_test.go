@@ -0,0 +1,86 @@
+package sync
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	redmine "github.com/uphy/go-redmine"
+)
+
+func TestConfigXLSXSheetNames(t *testing.T) {
+	c := testConverter()
+	long := "a project with a name longer than the sheet names [v2]"
+	c.Projects.names = append(c.Projects.names, redmine.IdName{Id: 3, Name: long})
+	config := &Config{Projects: []*Project{
+		{ID: 2, Tickets: []*Ticket{{ID: 7, Subject: stringPtr("x")}}},
+		{ID: 3, Tickets: []*Ticket{{ID: 8, Subject: stringPtr("y")}}},
+	}}
+	var b bytes.Buffer
+	if err := c.SaveConfigXLSX(&b, config); err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.readConfigXLSX(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertConfig(t, "sheet names", config, got)
+}
+
+func TestSaveConfigXLSXDataValidations(t *testing.T) {
+	config := &Config{Projects: []*Project{{ID: 1, Tickets: []*Ticket{{ID: 5, Subject: stringPtr("x")}}}}}
+	var b bytes.Buffer
+	if err := testConverter().SaveConfigXLSX(&b, config); err != nil {
+		t.Fatal(err)
+	}
+	z, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sheets := map[string]string{}
+	for _, f := range z.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		sheets[f.Name] = string(content)
+	}
+	// the dropdowns of the project sheet refer to the lists of the hidden sheet
+	for _, want := range []string{
+		`<dataValidations count="4">`,
+		`sqref="E2:E1048576"><formula1>'_lists'!$A$2:$A$4</formula1>`,
+		`sqref="H2:H1048576"><formula1>'_lists'!$B$2:$B$3</formula1>`,
+		`sqref="K2:K1048576"><formula1>'_lists'!$C$2:$C$2</formula1>`,
+		`sqref="D2:D1048576"><formula1>'_lists'!$D$2:$D$2</formula1>`,
+	} {
+		if !strings.Contains(sheets["xl/worksheets/sheet1.xml"], want) {
+			t.Errorf("want %s in the project sheet", want)
+		}
+	}
+	for _, want := range []string{"New", "In Progress", "Closed", "Bug", "Feature", "Normal", "Alice A"} {
+		if !strings.Contains(sheets["xl/worksheets/sheet2.xml"], `<t xml:space="preserve">`+want+"</t>") {
+			t.Errorf("want %s in the lists sheet", want)
+		}
+	}
+	if !strings.Contains(sheets["xl/workbook.xml"], `name="_lists" sheetId="2" state="hidden"`) {
+		t.Errorf("want the hidden lists sheet, got %s", sheets["xl/workbook.xml"])
+	}
+}
+
+func TestReadXLSXTicketsUnknownParent(t *testing.T) {
+	records := []xlsxRecord{
+		{row: 2, values: map[string]string{"ID": "5", "Subject": "parent"}},
+		{row: 3, values: map[string]string{"ID": "6", "Parent ID": "9", "Subject": "child"}},
+	}
+	err := readXLSXTickets(&Project{ID: 1}, records)
+	if err == nil || !strings.Contains(err.Error(), "row 3: no such parent in the sheet: 9") {
+		t.Errorf("want the unknown parent error, got %v", err)
+	}
+}
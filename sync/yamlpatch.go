@@ -0,0 +1,216 @@
+package sync
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	yaml3 "gopkg.in/yaml.v3"
+)
+
+// yamlInsertion is the text inserted at the column of the line, replacing the runes of the length.
+type yamlInsertion struct {
+	line   int
+	column int
+	length int
+	text   string
+	// order keeps the order of the insertions at the same position
+	order int
+}
+
+// isYAMLFile returns whether the config file of the name is written in YAML.
+func (c *Converter) isYAMLFile(name string) bool {
+	format, err := FormatOfFile(name)
+	return err == nil && format.Name() == "yaml"
+}
+
+// unmarshalYAMLNode parses the YAML text into the node tree.
+// yaml.v3 panics on some malformed texts instead of returning an error, so the panic is returned as the error.
+func unmarshalYAMLNode(src []byte, node *yaml3.Node) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to parse the YAML: %v", r)
+		}
+	}()
+	return yaml3.Unmarshal(src, node)
+}
+
+// patchYAML writes the IDs of the created tickets and the resolved or scheduled dates into the YAML text of the
+// config, and leaves the rest of the text untouched including the comments, the key order and the layout.
+// ok is false if the text doesn't match the config, for example when the file is modified during the import.
+func patchYAML(src []byte, config *Config) (patched []byte, ok bool, err error) {
+	var doc yaml3.Node
+	if err := unmarshalYAMLNode(src, &doc); err != nil {
+		return nil, false, err
+	}
+	if doc.Kind != yaml3.DocumentNode || len(doc.Content) != 1 || doc.Content[0].Kind != yaml3.MappingNode {
+		return nil, false, nil
+	}
+	insertions := []yamlInsertion{}
+	lines := strings.Split(string(src), "\n")
+	// replaceScalar replaces the single line scalar node with the plain text.
+	replaceScalar := func(node *yaml3.Node, text string) bool {
+		if node.Kind != yaml3.ScalarNode || node.Line < 1 || node.Line > len(lines) {
+			return false
+		}
+		source := node.Value
+		switch node.Style {
+		case 0:
+		case yaml3.DoubleQuotedStyle:
+			source = `"` + source + `"`
+		case yaml3.SingleQuotedStyle:
+			source = "'" + source + "'"
+		default:
+			return false
+		}
+		line := []rune(lines[node.Line-1])
+		start := node.Column - 1
+		end := start + len([]rune(source))
+		if start < 0 || end > len(line) || string(line[start:end]) != source {
+			return false
+		}
+		if source == "" {
+			// the empty value is placed just after the colon
+			if start == 0 || line[start-1] != ':' {
+				return false
+			}
+			text = " " + text
+		}
+		insertions = append(insertions, yamlInsertion{node.Line, node.Column, len([]rune(source)), text, len(insertions)})
+		return true
+	}
+
+	// insertKey inserts the key before the first key of the mapping node.
+	insertKey := func(mapping *yaml3.Node, key string, value string) {
+		first := mapping.Content[0]
+		text := fmt.Sprintf("%s: %s\n%s", key, value, strings.Repeat(" ", first.Column-1))
+		if mapping.Style&yaml3.FlowStyle != 0 {
+			text = fmt.Sprintf("%s: %s, ", key, value)
+		}
+		insertions = append(insertions, yamlInsertion{first.Line, first.Column, 0, text, len(insertions)})
+	}
+
+	var patchTickets func(node *yaml3.Node, tickets []*Ticket) bool
+	patchTickets = func(node *yaml3.Node, tickets []*Ticket) bool {
+		if node == nil || yamlNull(node) {
+			return len(tickets) == 0
+		}
+		if node.Kind != yaml3.SequenceNode || len(node.Content) != len(tickets) {
+			return false
+		}
+		for i, t := range tickets {
+			n := node.Content[i]
+			if n.Kind != yaml3.MappingNode || len(n.Content) == 0 {
+				return false
+			}
+			if id := yamlValue(n, "id"); id != nil {
+				v, err := strconv.Atoi(id.Value)
+				if err != nil || id.Style != 0 {
+					return false
+				}
+				if v == 0 && t.ID != 0 {
+					insertions = append(insertions, yamlInsertion{id.Line, id.Column, len(id.Value), strconv.Itoa(t.ID), len(insertions)})
+				} else if v != t.ID {
+					return false
+				}
+			} else if t.ID != 0 {
+				insertKey(n, "id", strconv.Itoa(t.ID))
+			}
+			for _, field := range []struct {
+				key  string
+				date *string
+			}{{"start_date", t.StartDate}, {"due_date", t.DueDate}} {
+				node := yamlValue(n, field.key)
+				if field.date == nil || *field.date == "" {
+					if node != nil && !yamlNull(node) && node.Value != "" {
+						return false
+					}
+					continue
+				}
+				if node == nil {
+					insertKey(n, field.key, *field.date)
+					continue
+				}
+				if node.Value != *field.date && !replaceScalar(node, *field.date) {
+					return false
+				}
+			}
+			if subject := yamlValue(n, "subject"); subject != nil {
+				value := ""
+				if t.Subject != nil {
+					value = *t.Subject
+				}
+				if !(yamlNull(subject) && value == "") && subject.Value != value {
+					return false
+				}
+			}
+			if !patchTickets(yamlValue(n, "children"), t.Children) {
+				return false
+			}
+		}
+		return true
+	}
+
+	projects := yamlValue(doc.Content[0], "projects")
+	if projects == nil || projects.Kind != yaml3.SequenceNode || len(projects.Content) != len(config.Projects) {
+		return nil, false, nil
+	}
+	for i, p := range config.Projects {
+		n := projects.Content[i]
+		if n.Kind != yaml3.MappingNode {
+			return nil, false, nil
+		}
+		if id := yamlValue(n, "id"); id == nil || id.Value != strconv.Itoa(p.ID) {
+			return nil, false, nil
+		}
+		if !patchTickets(yamlValue(n, "tickets"), p.Tickets) {
+			return nil, false, nil
+		}
+	}
+	if len(insertions) == 0 {
+		return src, true, nil
+	}
+
+	newline := "\n"
+	if strings.Contains(string(src), "\r\n") {
+		newline = "\r\n"
+	}
+	// insert from the end not to move the positions of the rest
+	sort.Slice(insertions, func(i, j int) bool {
+		if insertions[i].line != insertions[j].line {
+			return insertions[i].line > insertions[j].line
+		}
+		if insertions[i].column != insertions[j].column {
+			return insertions[i].column > insertions[j].column
+		}
+		return insertions[i].order > insertions[j].order
+	})
+	for _, ins := range insertions {
+		if ins.line < 1 || ins.line > len(lines) {
+			return nil, false, nil
+		}
+		line := []rune(lines[ins.line-1])
+		if ins.column < 1 || ins.column-1+ins.length > len(line) {
+			return nil, false, nil
+		}
+		text := strings.Replace(ins.text, "\n", newline, -1)
+		lines[ins.line-1] = string(line[:ins.column-1]) + text + string(line[ins.column-1+ins.length:])
+	}
+	return []byte(strings.Join(lines, "\n")), true, nil
+}
+
+// yamlNull reports whether the node is null, such as the empty value, null and ~.
+func yamlNull(node *yaml3.Node) bool {
+	return node.Kind == yaml3.ScalarNode && node.Tag == "!!null"
+}
+
+// yamlValue returns the value node of the key in the mapping node, or nil if the key is missing.
+func yamlValue(mapping *yaml3.Node, key string) *yaml3.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package sync
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPatchYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		tickets []*Ticket
+		want    string
+	}{
+		{
+			"unchanged",
+			"projects:\n- id: 1 # aaaa\n  tickets:\n  - id: 3\n    subject: a\n",
+			[]*Ticket{{ID: 3, Subject: stringPtr("a")}},
+			"projects:\n- id: 1 # aaaa\n  tickets:\n  - id: 3\n    subject: a\n",
+		},
+		{
+			"created ID",
+			"# tickets\nprojects:\n- id: 1\n  tickets:\n  - subject: a # first\n    tracker: Bug\n  - id: 0\n    subject: b\n",
+			[]*Ticket{{ID: 5, Subject: stringPtr("a")}, {ID: 6, Subject: stringPtr("b")}},
+			"# tickets\nprojects:\n- id: 1\n  tickets:\n  - id: 5\n    subject: a # first\n    tracker: Bug\n  - id: 6\n    subject: b\n",
+		},
+		{
+			"resolved dates",
+			"projects:\n- id: 1\n  tickets:\n  - id: 3\n    start_date: \"+1d\"  # tomorrow\n    due_date: 'start + 1w'\n",
+			[]*Ticket{{ID: 3, StartDate: stringPtr("2024-06-13"), DueDate: stringPtr("2024-06-20")}},
+			"projects:\n- id: 1\n  tickets:\n  - id: 3\n    start_date: 2024-06-13  # tomorrow\n    due_date: 2024-06-20\n",
+		},
+		{
+			"scheduled dates",
+			"projects:\n- id: 1\n  tickets:\n  - id: 3\n    start_date:\n    duration: 2\n",
+			[]*Ticket{{ID: 3, StartDate: stringPtr("2024-06-13"), DueDate: stringPtr("2024-06-14"), Duration: intPtr(2)}},
+			"projects:\n- id: 1\n  tickets:\n  - due_date: 2024-06-14\n    id: 3\n    start_date: 2024-06-13\n    duration: 2\n",
+		},
+		{
+			"null values",
+			"projects:\n- id: 1\n  tickets:\n  - id: 3\n    subject: null\n    start_date: null\n    due_date: ~\n",
+			[]*Ticket{{ID: 3, DueDate: stringPtr("2024-06-14")}},
+			"projects:\n- id: 1\n  tickets:\n  - id: 3\n    subject: null\n    start_date: null\n    due_date: 2024-06-14\n",
+		},
+		{
+			"children",
+			"projects:\n- id: 1\n  tickets:\n  - id: 3\n    children:\n    - subject: c\n",
+			[]*Ticket{{ID: 3, Children: []*Ticket{{ID: 4, Subject: stringPtr("c")}}}},
+			"projects:\n- id: 1\n  tickets:\n  - id: 3\n    children:\n    - id: 4\n      subject: c\n",
+		},
+		{
+			"flow mapping",
+			"projects:\n- id: 1\n  tickets:\n  - {subject: a}\n",
+			[]*Ticket{{ID: 5, Subject: stringPtr("a")}},
+			"projects:\n- id: 1\n  tickets:\n  - {id: 5, subject: a}\n",
+		},
+		{
+			"CRLF",
+			"projects:\r\n- id: 1\r\n  tickets:\r\n  - subject: a\r\n",
+			[]*Ticket{{ID: 5, Subject: stringPtr("a")}},
+			"projects:\r\n- id: 1\r\n  tickets:\r\n  - id: 5\r\n    subject: a\r\n",
+		},
+	}
+	for _, test := range tests {
+		config := &Config{Projects: []*Project{{ID: 1, Tickets: test.tickets}}}
+		patched, ok, err := patchYAML([]byte(test.src), config)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		if !ok {
+			t.Errorf("%s: not patched", test.name)
+			continue
+		}
+		if string(patched) != test.want {
+			t.Errorf("%s: want\n%s\ngot\n%s", test.name, test.want, patched)
+		}
+	}
+}
+
+func TestPatchYAMLMismatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		tickets []*Ticket
+	}{
+		{"modified subject", "projects:\n- id: 1\n  tickets:\n  - subject: a\n", []*Ticket{{ID: 5, Subject: stringPtr("b")}}},
+		{"added ticket", "projects:\n- id: 1\n  tickets:\n  - subject: a\n", []*Ticket{{Subject: stringPtr("a")}, {Subject: stringPtr("b")}}},
+		{"other ID", "projects:\n- id: 1\n  tickets:\n  - id: 3\n", []*Ticket{{ID: 4}}},
+		{"other project", "projects:\n- id: 2\n  tickets: []\n", nil},
+		{"removed date", "projects:\n- id: 1\n  tickets:\n  - id: 3\n    due_date: 2024-06-14\n", []*Ticket{{ID: 3}}},
+		{"multiline date", "projects:\n- id: 1\n  tickets:\n  - id: 3\n    due_date: |\n      +1d\n", []*Ticket{{ID: 3, DueDate: stringPtr("2024-06-13")}}},
+		{"not a config", "- a\n- b\n", nil},
+	}
+	for _, test := range tests {
+		config := &Config{Projects: []*Project{{ID: 1, Tickets: test.tickets}}}
+		patched, ok, err := patchYAML([]byte(test.src), config)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		if ok {
+			t.Errorf("%s: want the mismatch, got\n%s", test.name, strings.TrimSpace(string(patched)))
+		}
+	}
+}
+
+func TestPatchYAMLMalformed(t *testing.T) {
+	// yaml.v3 panics on this text
+	if _, _, err := patchYAML([]byte("#\n- ---\na"), &Config{}); err == nil {
+		t.Error("want an error")
+	}
+}